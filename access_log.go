@@ -0,0 +1,246 @@
+package bolt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat selects how AccessLog writes requests
+type AccessLogFormat uint8
+
+const (
+	AccessLogJSON     AccessLogFormat = iota // slog JSON records
+	AccessLogText                            // slog text records in logfmt
+	AccessLogCommon                          // Apache Common Log Format lines
+	AccessLogCombined                        // Apache Combined Log Format lines
+)
+
+// AccessLogField selects optional fields of JSON and logfmt records
+type AccessLogField uint16
+
+const (
+	FieldLatency AccessLogField = 1 << iota
+	FieldBytes
+	FieldRoute
+	FieldRequestID
+	FieldRealIP
+	FieldUser
+	FieldUserAgent
+	FieldReferer
+	FieldTrace
+
+	FieldsDefault = FieldLatency | FieldBytes | FieldRoute | FieldRequestID | FieldRealIP | FieldUser | FieldTrace
+)
+
+// AccessLogConfig configures the AccessLog middleware.
+type AccessLogConfig struct {
+	Format AccessLogFormat
+	Fields AccessLogField // JSON and logfmt only, defaults to FieldsDefault
+
+	// Logger receives JSON and logfmt records. Without Logger and Output it
+	// defaults to Config.Logger when the app has one. Otherwise records go to
+	// a JSON or text handler on Output, so the chosen Format applies.
+	Logger *slog.Logger
+	Output io.Writer // Defaults to os.Stdout
+
+	SampleRate    float64             // Fraction of ordinary requests logged, 0 for all
+	SlowThreshold time.Duration       // Slower requests are always logged, at warn level
+	SkipPaths     []string            // Exact paths never logged, e.g. "/healthz"
+	Skip          func(*Context) bool // Requests never logged
+}
+
+// AccessLog returns middleware that logs one line per request. Server errors
+// and slow requests are always logged; other requests are sampled by
+// SampleRate. Errors are passed to the error handler first so the final
+// status is logged. Requests that match no route run the app middleware too,
+// so they are logged with an empty route.
+func AccessLog(config ...AccessLogConfig) Middleware {
+	var cfg AccessLogConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Fields == 0 {
+		cfg.Fields = FieldsDefault
+	}
+	appLogger := cfg.Logger == nil && cfg.Output == nil
+	if cfg.Output == nil {
+		cfg.Output = os.Stdout
+	}
+	if cfg.Logger == nil {
+		switch cfg.Format {
+		case AccessLogJSON:
+			cfg.Logger = slog.New(slog.NewJSONHandler(cfg.Output, nil))
+		case AccessLogText:
+			cfg.Logger = slog.New(slog.NewTextHandler(cfg.Output, nil))
+		}
+	}
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = true
+	}
+	var mu sync.Mutex // Serializes Apache format lines
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if skip[c.Request.URL.Path] || cfg.Skip != nil && cfg.Skip(c) {
+				return next(c)
+			}
+			start := time.Now()
+			err := next(c)
+			c.handleError(err)
+			latency := time.Since(start)
+
+			status := c.ResponseStatus()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+			if status < 500 && !slow && cfg.SampleRate > 0 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+				return nil
+			}
+
+			switch cfg.Format {
+			case AccessLogCommon, AccessLogCombined:
+				line := apacheLogLine(c, start, status, cfg.Format == AccessLogCombined)
+				mu.Lock()
+				_, _ = cfg.Output.Write(line)
+				mu.Unlock()
+			default:
+				level := slog.LevelInfo
+				switch {
+				case status >= 500:
+					level = slog.LevelError
+				case slow:
+					level = slog.LevelWarn
+				}
+				logger := cfg.Logger
+				if appLogger && c.app != nil && c.app.config.Logger != nil {
+					logger = c.app.config.Logger
+				}
+				logger.LogAttrs(context.Background(), level, "request", accessLogAttrs(c, cfg.Fields, status, latency, slow, err)...)
+			}
+			return nil
+		}
+	}
+}
+
+// accessLogAttrs returns the record attributes for a finished request.
+func accessLogAttrs(c *Context, fields AccessLogField, status int, latency time.Duration, slow bool, err error) []slog.Attr {
+	r := c.Request
+	attrs := make([]slog.Attr, 0, 14)
+	attrs = append(attrs,
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+	)
+	if fields&FieldRoute != 0 && c.route != "" {
+		attrs = append(attrs, slog.String("route", c.route))
+	}
+	if fields&FieldLatency != 0 {
+		attrs = append(attrs, slog.Duration("latency", latency))
+	}
+	if fields&FieldBytes != 0 {
+		attrs = append(attrs, slog.Int64("bytes", c.ResponseSize()))
+	}
+	if fields&FieldRealIP != 0 {
+		attrs = append(attrs, slog.String("ip", c.RealIP()))
+	}
+	if fields&FieldUser != 0 {
+		if p := c.Principal(); p != nil {
+			attrs = append(attrs, slog.String("user", p.Name))
+		}
+	}
+	if fields&FieldRequestID != 0 {
+		if id := c.RequestID(); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	if fields&FieldTrace != 0 {
+		if sc, ok := c.SpanContext(); ok {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+		}
+	}
+	if fields&FieldUserAgent != 0 {
+		if ua := r.UserAgent(); ua != "" {
+			attrs = append(attrs, slog.String("user_agent", ua))
+		}
+	}
+	if fields&FieldReferer != 0 {
+		if ref := r.Referer(); ref != "" {
+			attrs = append(attrs, slog.String("referer", ref))
+		}
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	return attrs
+}
+
+// apacheLogLine formats a Common or Combined Log Format line:
+//
+//	host - user [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.1" 200 2326 "referer" "agent"
+func apacheLogLine(c *Context, start time.Time, status int, combined bool) []byte {
+	r := c.Request
+	user := ""
+	if p := c.Principal(); p != nil {
+		user = p.Name
+	}
+	b := make([]byte, 0, 256)
+	b = append(b, c.RealIP()...)
+	b = append(b, " - "...)
+	b = appendLogField(b, orDash(user))
+	b = append(b, " ["...)
+	b = start.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, `] "`...)
+	b = appendLogField(b, r.Method+" "+r.RequestURI+" "+r.Proto)
+	b = append(b, `" `...)
+	b = strconv.AppendInt(b, int64(status), 10)
+	b = append(b, ' ')
+	if size := c.ResponseSize(); size > 0 {
+		b = strconv.AppendInt(b, size, 10)
+	} else {
+		b = append(b, '-')
+	}
+	if combined {
+		b = append(b, ` "`...)
+		b = appendLogField(b, orDash(r.Referer()))
+		b = append(b, `" "`...)
+		b = appendLogField(b, orDash(r.UserAgent()))
+		b = append(b, '"')
+	}
+	return append(b, '\n')
+}
+
+// appendLogField appends s with quotes, backslashes and control characters
+// escaped, so request data cannot forge log lines.
+func appendLogField(b []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"' || ch == '\\':
+			b = append(b, '\\', ch)
+		case ch < 0x20 || ch == 0x7f:
+			b = append(b, '\\', 'x', hexDigits[ch>>4], hexDigits[ch&0x0f])
+		default:
+			b = append(b, ch)
+		}
+	}
+	return b
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,22 +2,36 @@ package bolt
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
 // Pre-serialized JSON error responses to avoid allocations in the error handler.
 var (
-	errNotFoundResponse      = []byte(`{"error":"Not Found"}`)
-	errBadRequestResponse    = []byte(`{"error":"Bad Request"}`)
-	errInternalServerResponse = []byte(`{"error":"Internal Server Error"}`)
+	errNotFoundResponse        = []byte(`{"error":"Not Found"}`)
+	errBadRequestResponse      = []byte(`{"error":"Bad Request"}`)
+	errUnauthorizedResponse    = []byte(`{"error":"Unauthorized"}`)
+	errForbiddenResponse       = []byte(`{"error":"Forbidden"}`)
+	errUpgradeRequiredResponse = []byte(`{"error":"Upgrade Required"}`)
+	errTooLargeResponse        = []byte(`{"error":"Request Entity Too Large"}`)
+	errPreconditionResponse    = []byte(`{"error":"Precondition Failed"}`)
+	errRangeResponse           = []byte(`{"error":"Requested Range Not Satisfiable"}`)
+	errMediaTypeResponse       = []byte(`{"error":"Unsupported Media Type"}`)
+	errTooManyResponse         = []byte(`{"error":"Too Many Requests"}`)
+	errUnavailableResponse     = []byte(`{"error":"Service Unavailable"}`)
+	errGatewayTimeoutResponse  = []byte(`{"error":"Gateway Timeout"}`)
+	errClientClosedResponse    = []byte(`{"error":"Client Closed Request"}`)
+	errInternalServerResponse  = []byte(`{"error":"Internal Server Error"}`)
 )
 
 // TypedHandlerInfo caches reflection information for typed handlers
@@ -46,6 +60,30 @@ type App struct {
 	server       *Server
 	prefix       string
 	parentGroup  *RouteGroup   // The group this App instance belongs to
+	proxies      []netip.Prefix // Parsed Config.TrustedProxies
+	options      map[string]*pathOptions // Auto-OPTIONS state per path, shared with groups
+	notFound     atomic.Pointer[notFoundChain] // 404 handler compiled with the app middleware
+}
+
+// notFoundChain is the app middleware compiled around the 404 handler
+type notFoundChain struct {
+	middleware int // len(App.middleware) when compiled
+	handler    Handler
+}
+
+// notFoundHandler returns the handler for requests without a route. It runs
+// the app middleware, so unmatched requests are logged, counted and traced
+// like any other, then returns ErrNotFound.
+func (a *App) notFoundHandler() Handler {
+	if nf := a.notFound.Load(); nf != nil && nf.middleware == len(a.middleware) {
+		return nf.handler
+	}
+	nf := &notFoundChain{
+		middleware: len(a.middleware),
+		handler:    compileMiddleware(a.middleware, func(*Context) error { return ErrNotFound }),
+	}
+	a.notFound.Store(nf)
+	return nf.handler
 }
 
 // New creates a new top-level App
@@ -65,12 +103,18 @@ func New(opts ...Option) *App {
 		pathBuilder:  newPathBuilder(),
 		parentGroup:  nil, // A new app has no parent
 	}
+	app.proxies = parsePrefixes(config.TrustedProxies)
+	app.options = make(map[string]*pathOptions)
 
 	if config.EnablePooling {
 		app.contextPool = NewContextPool()
 		app.bufferPool = NewBufferPool()
 	}
 
+	if config.HandlerTimeout > 0 {
+		app.middleware = append(app.middleware, handlerTimeout(config.HandlerTimeout))
+	}
+
 	return app
 }
 
@@ -121,6 +165,7 @@ func (a *App) Options(path string, handler Handler) *ChainLink {
 }
 
 // PostJSON registers a POST route with automatic JSON parsing.
+// Form and multipart bodies are bound as well, based on Content-Type.
 func (a *App) PostJSON(path string, handler interface{}) *ChainLink {
 	wrappedHandler := wrapTypedHandler(handler)
 	return a.addRoute(MethodPost, path, wrappedHandler)
@@ -159,28 +204,138 @@ func compileMiddleware(middleware []Middleware, finalHandler Handler) Handler {
 
 // addRoute adds a route to the router with simplified middleware compilation.
 func (a *App) addRoute(method HTTPMethod, path string, handler Handler) *ChainLink {
+	return a.addRouteInfo(method, path, handler, false)
+}
+
+// addStreamingRoute adds a WebSocket or SSE route, which Config.HandlerTimeout
+// leaves alone.
+func (a *App) addStreamingRoute(method HTTPMethod, path string, handler Handler) *ChainLink {
+	return a.addRouteInfo(method, path, handler, true)
+}
+
+func (a *App) addRouteInfo(method HTTPMethod, path string, handler Handler, streaming bool) *ChainLink {
 	// Use the path builder to avoid string concatenation allocations
 	fullPath := a.pathBuilder.build(a.prefix, path)
 
+	routeInfo := &RouteInfo{
+		Method:     method,
+		Path:       fullPath,
+		Handler:    handler, // Store original handler for documentation
+		Group:      a.parentGroup,
+		middleware: a.middleware,
+		streaming:  streaming,
+	}
+
 	// Apply middleware compilation directly
 	finalHandler := compileMiddleware(a.middleware, handler)
-	a.router.AddRoute(method, fullPath, finalHandler)
+	a.router.AddRoute(method, fullPath, routeInfo.withRoute(finalHandler))
 
-	routeInfo := &RouteInfo{
-		Method:  method,
-		Path:    fullPath,
-		Handler: handler, // Store original handler for documentation
-		Group:   a.parentGroup,
-	}
 	a.routes = append(a.routes, *routeInfo)
+	a.pathOptions(fullPath)
 
 	return &ChainLink{app: a, subject: routeInfo}
 }
 
+// withRoute records the matched route pattern before running h.
+func withRoute(pattern string, h Handler) Handler {
+	return func(c *Context) error {
+		c.route = pattern
+		return h(c)
+	}
+}
+
+// withRoute records the route before running h, flagging streaming routes
+// before any middleware sees the request.
+func (r *RouteInfo) withRoute(h Handler) Handler {
+	if !r.streaming {
+		return withRoute(r.Path, h)
+	}
+	pattern := r.Path
+	return func(c *Context) error {
+		c.route = pattern
+		c.streaming = true
+		return h(c)
+	}
+}
+
+// Route returns the pattern of the matched route, e.g. "/users/:id", or ""
+// when no route matched. Use it instead of the raw path to label metrics.
+func (c *Context) Route() string {
+	return c.route
+}
+
+// handleError runs the error handler right away, so middleware observing
+// the response, such as tracing and metrics, sees the final status.
+func (c *Context) handleError(err error) {
+	if err != nil && c.app != nil {
+		c.app.errorHandler(c, err)
+	}
+}
+
+// methodAutoOptions keys the OPTIONS fallback registered once for every
+// route path. It runs the app middleware of the path's first route, so CORS
+// policies installed with Use can answer preflight requests for paths without
+// an explicit Options route. Requests no policy answers get ErrNotFound, as
+// if the fallback did not exist.
+const methodAutoOptions HTTPMethod = "bolt-auto-options"
+
+// pathOptions is the auto-OPTIONS handler of one path. Route-level CORS
+// policies are kept per method, so a preflight is answered by the policy of
+// the route named in Access-Control-Request-Method. They run before the app
+// middleware, so authentication installed with Use does not reject
+// preflights the route's policy answers.
+type pathOptions struct {
+	preflight map[HTTPMethod]Handler
+	fallback  Handler // App middleware around ErrNotFound
+}
+
+func newPathOptions(middleware []Middleware) *pathOptions {
+	return &pathOptions{
+		preflight: make(map[HTTPMethod]Handler),
+		fallback:  compileMiddleware(middleware, func(*Context) error { return ErrNotFound }),
+	}
+}
+
+// pathOptions returns the auto-OPTIONS state of path, registering the
+// handler on first use.
+func (a *App) pathOptions(path string) *pathOptions {
+	p := a.options[path]
+	if p == nil {
+		p = newPathOptions(a.middleware)
+		a.options[path] = p
+		a.router.AddRoute(methodAutoOptions, path, withRoute(path, p.serve))
+	}
+	return p
+}
+
+// addPreflight wraps the preflight answer for route's method with mw. Later
+// calls wrap earlier ones, matching ChainLink layering.
+func (a *App) addPreflight(route *RouteInfo, mw Middleware) {
+	a.pathOptions(route.Path).add(route.Method, mw)
+}
+
+// add wraps the preflight answer for method with mw.
+func (p *pathOptions) add(method HTTPMethod, mw Middleware) {
+	h := p.preflight[method]
+	if h == nil {
+		h = p.fallback
+	}
+	p.preflight[method] = mw(h)
+}
+
+// serve dispatches preflights to the requested method's policy.
+func (p *pathOptions) serve(c *Context) error {
+	if h := p.preflight[HTTPMethod(c.Request.Header.Get("Access-Control-Request-Method"))]; h != nil {
+		return h(c)
+	}
+	return p.fallback(c)
+}
+
 // Group creates a route group.
 func (a *App) Group(prefix string, fn GroupFunc) *ChainLink {
 	group := &RouteGroup{
 		Prefix: a.pathBuilder.build(a.prefix, prefix),
+		parent: a.parentGroup,
 	}
 
 	subApp := &App{
@@ -194,6 +349,7 @@ func (a *App) Group(prefix string, fn GroupFunc) *ChainLink {
 		pathBuilder:  a.pathBuilder,
 		prefix:       group.Prefix,
 		parentGroup:  group,
+		options:      a.options,
 	}
 
 	fn(subApp)
@@ -263,7 +419,7 @@ func wrapTypedHandler(handler interface{}) Handler {
 		bodyValue := reflect.New(info.BodyType)
 		bodyPtr := bodyValue.Interface()
 
-		if err := c.BindJSON(bodyPtr); err != nil {
+		if err := c.Bind(bodyPtr); err != nil {
 			return err
 		}
 
@@ -279,46 +435,38 @@ func wrapTypedHandler(handler interface{}) Handler {
 	}
 }
 
-// cachedHeaderWriter wraps http.ResponseWriter to return cached headers
-type cachedHeaderWriter struct {
-	http.ResponseWriter
-	cachedHeaders http.Header
-}
-
-// Header returns the cached headers instead of calling the underlying writer's Header()
-func (w *cachedHeaderWriter) Header() http.Header {
-	return w.cachedHeaders
-}
-
 // ServeHTTP is the main entry point for handling requests - optimized for speed.
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	handler, params := a.router.GetValue(HTTPMethod(r.Method), r.URL.Path)
 	if handler == nil {
-		headers := w.Header()
-		wrappedWriter := &cachedHeaderWriter{ResponseWriter: w, cachedHeaders: headers}
-		c := &Context{Request: r, Response: wrappedWriter, app: a, headers: headers}
-		a.errorHandler(c, ErrNotFound)
-		return
+		handler = a.router.GetMount(HTTPMethod(r.Method), r.URL.Path)
+	}
+	if handler == nil && r.Method == http.MethodOptions {
+		handler, params = a.router.GetValue(methodAutoOptions, r.URL.Path)
+		if handler == nil {
+			handler = a.router.GetMount(methodAutoOptions, r.URL.Path)
+		}
+	}
+	if handler == nil {
+		handler = a.notFoundHandler()
 	}
 
 	var c *Context
 	if a.config.EnablePooling && a.contextPool != nil {
 		c = a.contextPool.Acquire()
-		defer a.contextPool.Release(c)
 	} else {
 		c = &Context{}
 	}
+	defer a.releaseContext(c)
 
-	// Cache headers once to avoid repeated Header() calls in middleware/handlers
-	headers := w.Header()
-	wrappedWriter := &cachedHeaderWriter{ResponseWriter: w, cachedHeaders: headers}
-
-	// Initialize context with minimal overhead
+	// Initialize context with minimal overhead. The writer caches headers once
+	// to avoid repeated Header() calls in middleware/handlers.
 	c.Request = r
-	c.Response = wrappedWriter
+	c.serverReq = r
+	c.Response = c.writer.reset(w)
 	c.app = a
 	c.params = params
-	c.headers = headers
+	c.headers = c.writer.header
 
 	// Fast path: Static routes with no query (most common case for middleware benchmarks)
 	if params == nil && r.URL.RawQuery == "" {
@@ -343,13 +491,24 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err := handler(c); err != nil {
 		a.errorHandler(c, err)
 	}
+}
 
-	if params != nil && a.router.paramPool != nil {
-		a.router.releaseParamMap(params)
+// releaseContext returns c and its params to their pools once no handler holds it.
+func (a *App) releaseContext(c *Context) {
+	if atomic.AddInt32(&c.refs, -1) >= 0 {
+		return
+	}
+	c.removeMultipartForm(c.serverReq)
+	if c.params != nil && a.router.paramPool != nil {
+		a.router.releaseParamMap(c.params)
+	}
+	if a.config.EnablePooling && a.contextPool != nil {
+		a.contextPool.Release(c)
 	}
 }
 
 // DefaultErrorHandler provides a zero-allocation error handling mechanism.
+// Request and trace IDs, when set, are added to the JSON body.
 func DefaultErrorHandler(c *Context, err error) {
 	if err == nil {
 		return
@@ -362,17 +521,59 @@ func DefaultErrorHandler(c *Context, err error) {
 	case ErrNotFound:
 		code = http.StatusNotFound
 		body = errNotFoundResponse
-	case ErrBadRequest:
+	case ErrBadRequest, ErrUnsafePath, ErrInvalidCookie:
 		code = http.StatusBadRequest
 		body = errBadRequestResponse
+	case ErrUnauthorized:
+		code = http.StatusUnauthorized
+		body = errUnauthorizedResponse
+		if c.headers.Get("WWW-Authenticate") == "" {
+			c.headers.Set("WWW-Authenticate", "Bearer")
+		}
+	case ErrForbidden:
+		code = http.StatusForbidden
+		body = errForbiddenResponse
+	case ErrUpgradeRequired:
+		code = http.StatusUpgradeRequired
+		body = errUpgradeRequiredResponse
+	case ErrRequestTooLarge, ErrFileTooLarge:
+		code = http.StatusRequestEntityTooLarge
+		body = errTooLargeResponse
+	case ErrPreconditionFailed:
+		code = http.StatusPreconditionFailed
+		body = errPreconditionResponse
+	case ErrRangeNotSatisfiable:
+		code = http.StatusRequestedRangeNotSatisfiable
+		body = errRangeResponse
+	case ErrUnsupportedEncoding:
+		code = http.StatusUnsupportedMediaType
+		body = errMediaTypeResponse
+	case ErrTooManyRequests:
+		code = http.StatusTooManyRequests
+		body = errTooManyResponse
+	case ErrTimeout:
+		code = http.StatusServiceUnavailable
+		body = errUnavailableResponse
+	case context.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+		body = errGatewayTimeoutResponse
+	case ErrClientClosed, context.Canceled:
+		code = StatusClientClosedRequest
+		body = errClientClosedResponse
 	default:
-		code = http.StatusInternalServerError
-		body = errInternalServerResponse
+		var csrfErr *CSRFError
+		if errors.As(err, &csrfErr) {
+			code = http.StatusForbidden
+			body = csrfErr.responseBody()
+		} else {
+			code = http.StatusInternalServerError
+			body = errInternalServerResponse
+		}
 	}
 
 	// Avoid writing header twice
-	if c.StatusCode == 0 {
-		_ = c.Bytes(StatusCode(code), ContentTypeJSON, body)
+	if c.StatusCode == 0 && !c.Written() {
+		_ = c.Bytes(StatusCode(code), ContentTypeJSON, c.errorBody(body))
 	}
 }
 
@@ -395,7 +596,7 @@ func (a *App) setupDocs() {
 	uiPath := a.config.DocsConfig.UIPath
 	a.Get(uiPath, ServeSwaggerUI(specPath))
 	if a.config.DevMode && a.server != nil && a.server.Addr != "" {
-		log.Printf("📚 API Documentation available at: http://localhost%s%s", a.server.Addr, uiPath)
+		a.logger().Info("bolt: API documentation available", "url", "http://localhost"+a.server.Addr+uiPath)
 	}
 }
 
@@ -410,7 +611,7 @@ func (a *App) Listen(addr string) error {
 	}
 	go a.handleShutdown()
 	if a.config.DevMode {
-		log.Printf("🚀 Server starting on http://localhost%s", addr)
+		a.logger().Info("bolt: server starting", "url", "http://localhost"+addr)
 	}
 	a.setupDocs()
 	listener, err := net.Listen("tcp", addr)
@@ -425,13 +626,37 @@ func (a *App) handleShutdown() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	log.Println("🛑 Shutting down server...")
+	a.logger().Info("bolt: shutting down server")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := a.server.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		a.logger().Error("bolt: server forced to shut down", "error", err)
+	}
+	a.logger().Info("bolt: server stopped")
+}
+
+// logger returns Config.Logger, or the slog default logger.
+func (a *App) logger() *slog.Logger {
+	if a.config.Logger != nil {
+		return a.config.Logger
+	}
+	return slog.Default()
+}
+
+// Logger returns the app logger with the request and trace IDs attached,
+// when the RequestID or tracing middleware set them.
+func (c *Context) Logger() *slog.Logger {
+	l := slog.Default()
+	if c.app != nil {
+		l = c.app.logger()
+	}
+	if id := c.RequestID(); id != "" {
+		l = l.With("request_id", id)
+	}
+	if sc, ok := c.SpanContext(); ok {
+		l = l.With("trace_id", sc.TraceID.String(), "span_id", sc.SpanID.String())
 	}
-	log.Println("✅ Server stopped")
+	return l
 }
 
 // Shutdown provides a way to programmatically shut down the server.
@@ -0,0 +1,239 @@
+package bolt
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Locals keys used by the auth middlewares
+const (
+	ClaimsKey    = "bolt.claims"    // Claims verified by JWTAuth
+	PrincipalKey = "bolt.principal" // *Principal of any auth middleware
+)
+
+// Principal is the authenticated caller
+type Principal struct {
+	Name   string      // User name, key owner or JWT subject
+	Scheme string      // "bearer", "basic", "digest" or "apikey"
+	Value  interface{} // Scheme-specific data, e.g. Claims or an API key record
+}
+
+// Principal returns the caller authenticated by an auth middleware, or nil.
+func (c *Context) Principal() *Principal {
+	p, _ := Local[*Principal](c, PrincipalKey)
+	return p
+}
+
+// JWTConfig configures the JWTAuth middleware.
+type JWTConfig struct {
+	Keys      *KeySet               // Verification keys, required
+	Issuer    string                // Required iss value, empty to skip
+	Audience  []string              // Accepted aud values, empty to skip
+	ClockSkew time.Duration         // Tolerance for exp and nbf
+	Realm     string                // Realm reported in WWW-Authenticate
+	Optional  bool                  // Let requests without a token through
+	Token     func(*Context) string // Token source, defaults to the Bearer Authorization header
+}
+
+// JWTAuth returns middleware that verifies bearer JWTs signed with HS256,
+// RS256, ES256 or EdDSA. Verified claims are stored in the request locals
+// (see Context.Claims). Invalid or missing tokens return ErrUnauthorized
+// with a WWW-Authenticate challenge describing the problem.
+func JWTAuth(config JWTConfig) Middleware {
+	if config.Keys == nil {
+		panic("bolt: JWTAuth requires a KeySet")
+	}
+	if config.Token == nil {
+		config.Token = bearerToken
+	}
+	realm := ""
+	if config.Realm != "" {
+		realm = `realm="` + config.Realm + `"`
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			token := config.Token(c)
+			if token == "" {
+				if config.Optional {
+					return next(c)
+				}
+				c.headers.Set("WWW-Authenticate", bearerChallenge(realm))
+				return ErrUnauthorized
+			}
+			claims, err := config.Keys.VerifyJWT(token)
+			if err == nil {
+				err = config.validate(claims, time.Now())
+			}
+			if err != nil {
+				c.headers.Set("WWW-Authenticate", bearerChallenge(realm,
+					`error="invalid_token"`, `error_description="`+err.Error()+`"`))
+				return ErrUnauthorized
+			}
+			c.Set(ClaimsKey, claims)
+			c.Set(PrincipalKey, &Principal{Name: claims.Subject(), Scheme: "bearer", Value: claims})
+			return next(c)
+		}
+	}
+}
+
+// validate checks the registered time, issuer and audience claims.
+func (config *JWTConfig) validate(claims Claims, now time.Time) error {
+	exp, ok, err := claims.numericDate("exp")
+	if err != nil {
+		return err
+	}
+	if ok && !now.Before(exp.Add(config.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	nbf, ok, err := claims.numericDate("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(config.ClockSkew).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+	if config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != config.Issuer {
+			return ErrTokenIssuer
+		}
+	}
+	if len(config.Audience) > 0 {
+		matched := false
+		for _, aud := range claims.audiences() {
+			if slices.Contains(config.Audience, aud) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return ErrTokenAudience
+		}
+	}
+	return nil
+}
+
+// bearerToken returns the token of a "Bearer" Authorization header.
+func bearerToken(c *Context) string {
+	auth := c.Request.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// bearerChallenge builds a Bearer WWW-Authenticate value from auth-params.
+func bearerChallenge(params ...string) string {
+	var b strings.Builder
+	b.WriteString("Bearer")
+	sep := " "
+	for _, p := range params {
+		if p == "" {
+			continue
+		}
+		b.WriteString(sep)
+		b.WriteString(p)
+		sep = ", "
+	}
+	return b.String()
+}
+
+// Claims returns the claims verified by JWTAuth, or nil if there are none.
+func (c *Context) Claims() Claims {
+	claims, _ := Local[Claims](c, ClaimsKey)
+	return claims
+}
+
+// Require enforces that the request carries verified JWT claims granting all
+// scopes, on the current route or on every route in the current group.
+// JWTAuth must run earlier in the chain. Requests without claims get
+// ErrUnauthorized, those lacking a scope ErrForbidden. The requirement is
+// also published in the OpenAPI document.
+func (cl *ChainLink) Require(scopes ...string) *ChainLink {
+	mw := requireScopes(scopes)
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, mw)
+		cl.app.addSecurity(v, SecurityBearer, bearerScheme, scopes)
+	case *staticMount:
+		cl.app.rewrapMount(v, mw)
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if r := &cl.app.routes[i]; v.contains(r.Group) {
+				cl.app.rewrapRoute(r, mw)
+				cl.app.addSecurity(r, SecurityBearer, bearerScheme, scopes)
+			}
+		}
+	}
+	return cl
+}
+
+// withAuth applies an auth middleware to the current route or group and
+// documents its security scheme.
+func (cl *ChainLink) withAuth(mw Middleware, name string, scheme SecurityScheme) *ChainLink {
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, mw)
+		cl.app.addSecurity(v, name, scheme, nil)
+	case *staticMount:
+		cl.app.rewrapMount(v, mw)
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if r := &cl.app.routes[i]; v.contains(r.Group) {
+				cl.app.rewrapRoute(r, mw)
+				cl.app.addSecurity(r, name, scheme, nil)
+			}
+		}
+	}
+	return cl
+}
+
+// requireScopes returns middleware that checks the claims for scopes.
+func requireScopes(scopes []string) Middleware {
+	scopeParam := `scope="` + strings.Join(scopes, " ") + `"`
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			claims := c.Claims()
+			if claims == nil {
+				c.headers.Set("WWW-Authenticate", bearerChallenge(scopeParam))
+				return ErrUnauthorized
+			}
+			for _, s := range scopes {
+				if !claims.HasScope(s) {
+					c.headers.Set("WWW-Authenticate", bearerChallenge(`error="insufficient_scope"`, scopeParam))
+					return ErrForbidden
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// OpenAPI security scheme names used by the auth middlewares
+const (
+	SecurityBearer = "bearerAuth"
+	SecurityBasic  = "basicAuth"
+	SecurityDigest = "digestAuth"
+	SecurityAPIKey = "apiKeyAuth"
+)
+
+// bearerScheme is the OpenAPI definition of JWT bearer auth
+var bearerScheme = SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+
+// routeSecurity is a documented auth requirement of a route
+type routeSecurity struct {
+	name   string
+	scheme SecurityScheme
+	scopes []string
+}
+
+// addSecurity records a security requirement for a route's documentation.
+func (a *App) addSecurity(route *RouteInfo, name string, scheme SecurityScheme, scopes []string) {
+	route.security = append(route.security, routeSecurity{name: name, scheme: scheme, scopes: slices.Clone(scopes)})
+	for i := range a.routes {
+		if a.routes[i].Method == route.Method && a.routes[i].Path == route.Path {
+			a.routes[i].security = route.security
+		}
+	}
+}
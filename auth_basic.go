@@ -0,0 +1,266 @@
+package bolt
+
+import (
+	"bufio"
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordVerifier reports whether password matches an encoded hash
+type PasswordVerifier func(hash, password string) bool
+
+// BasicAuthConfig configures the BasicAuth middleware.
+//
+// Users maps user names to password hashes. "$pbkdf2-sha256$" hashes (see
+// HashPassword), bcrypt hashes as written by htpasswd -B ("$2a$", "$2b$",
+// "$2y$") and argon2 hashes in PHC format ("$argon2id$", "$argon2i$") are
+// verified natively, and "{PLAIN}" values are compared in constant time.
+// Other formats need a verifier keyed by hash prefix.
+//
+// Hash checks are expensive by design, so at most HashConcurrency of them run
+// at once; further requests wait for a slot or until they are canceled. This
+// bounds the CPU that failed or unknown-user attempts can consume.
+type BasicAuthConfig struct {
+	Users           map[string]string           // User name to password hash
+	Verifiers       map[string]PasswordVerifier // Extra hash formats by prefix
+	Realm           string                      // Defaults to "Restricted"
+	CacheTTL        time.Duration               // How long a verified password is remembered, 0 for one minute
+	HashConcurrency int                         // Hash checks run at once, 0 for GOMAXPROCS
+}
+
+// BasicAuth returns middleware for HTTP Basic authentication. The user is
+// stored as the request Principal. Hash checks are slow by design, so
+// successful verifications are cached for CacheTTL.
+func BasicAuth(config BasicAuthConfig) Middleware {
+	if config.Realm == "" {
+		config.Realm = "Restricted"
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = time.Minute
+	}
+	if config.HashConcurrency <= 0 {
+		config.HashConcurrency = runtime.GOMAXPROCS(0)
+	}
+	challenge := `Basic realm="` + config.Realm + `", charset="UTF-8"`
+	v := &basicVerifier{
+		config: &config,
+		cache:  credentialCache{ttl: config.CacheTTL, entries: make(map[[32]byte]time.Time)},
+		slots:  make(chan struct{}, config.HashConcurrency),
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			user, password, ok := c.Request.BasicAuth()
+			if !ok || !v.verify(c.Request.Context(), user, password) {
+				c.headers.Set("WWW-Authenticate", challenge)
+				return ErrUnauthorized
+			}
+			c.Set(PrincipalKey, &Principal{Name: user, Scheme: "basic"})
+			return next(c)
+		}
+	}
+}
+
+// BasicAuth protects the current route or group with HTTP Basic
+// authentication and documents it in the OpenAPI document.
+func (cl *ChainLink) BasicAuth(config BasicAuthConfig) *ChainLink {
+	return cl.withAuth(BasicAuth(config), SecurityBasic, SecurityScheme{Type: "http", Scheme: "basic"})
+}
+
+// dummyHash is verified for unknown users so response times do not reveal
+// which user names exist.
+var dummyHash = sync.OnceValue(func() string { return HashPassword("bolt-dummy-password") })
+
+// basicVerifier checks credentials for one BasicAuth middleware
+type basicVerifier struct {
+	config *BasicAuthConfig
+	cache  credentialCache
+	slots  chan struct{} // Bounds concurrent hash checks
+}
+
+// verify checks the password of user. It gives up when ctx is canceled
+// while waiting for a hash slot.
+func (v *basicVerifier) verify(ctx context.Context, user, password string) bool {
+	hash, known := v.config.Users[user]
+	key := sha256.Sum256([]byte(user + "\x00" + password + "\x00" + hash))
+	if known && v.cache.hit(key) {
+		return true
+	}
+
+	select {
+	case v.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	defer func() { <-v.slots }()
+
+	if !known {
+		verifyPassword(dummyHash(), password, nil)
+		return false
+	}
+	if !verifyPassword(hash, password, v.config.Verifiers) {
+		return false
+	}
+	v.cache.add(key)
+	return true
+}
+
+// credentialCache remembers recently verified credentials by digest
+type credentialCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[32]byte]time.Time
+}
+
+const credentialCacheSize = 1024
+
+// hit reports whether key was verified within the TTL.
+func (cc *credentialCache) hit(key [32]byte) bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	exp, ok := cc.entries[key]
+	return ok && time.Now().Before(exp)
+}
+
+// add remembers key, dropping all entries when the cache is full.
+func (cc *credentialCache) add(key [32]byte) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.entries) >= credentialCacheSize {
+		clear(cc.entries)
+	}
+	cc.entries[key] = time.Now().Add(cc.ttl)
+}
+
+// --- Password hashes ---
+
+// pbkdf2Iterations is the work factor of HashPassword (OWASP 2023)
+const pbkdf2Iterations = 600000
+
+// HashPassword hashes a password with PBKDF2-SHA256 in the passlib format
+// "$pbkdf2-sha256$<iterations>$<salt>$<hash>".
+func HashPassword(password string) string {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, pbkdf2Iterations, sha256.Size)
+	if err != nil {
+		panic(err)
+	}
+	return "$pbkdf2-sha256$" + strconv.Itoa(pbkdf2Iterations) + "$" + ab64Encode(salt) + "$" + ab64Encode(key)
+}
+
+// verifyPassword checks password against an encoded hash.
+func verifyPassword(hash, password string, verifiers map[string]PasswordVerifier) bool {
+	if plain, ok := strings.CutPrefix(hash, "{PLAIN}"); ok {
+		a := sha256.Sum256([]byte(plain))
+		b := sha256.Sum256([]byte(password))
+		return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+	}
+	if rest, ok := strings.CutPrefix(hash, "$pbkdf2-sha256$"); ok {
+		parts := strings.Split(rest, "$")
+		if len(parts) != 3 {
+			return false
+		}
+		iter, err := strconv.Atoi(parts[0])
+		if err != nil || iter < 1 {
+			return false
+		}
+		salt, err1 := ab64Decode(parts[1])
+		want, err2 := ab64Decode(parts[2])
+		if err1 != nil || err2 != nil || len(want) == 0 {
+			return false
+		}
+		got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+		return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+	}
+	if strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	if strings.HasPrefix(hash, "$argon2id$") || strings.HasPrefix(hash, "$argon2i$") {
+		return verifyArgon2(hash, password)
+	}
+	for prefix, verify := range verifiers {
+		if strings.HasPrefix(hash, prefix) {
+			return verify(hash, password)
+		}
+	}
+	return false
+}
+
+// verifyArgon2 checks password against a PHC string such as
+// "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>".
+func verifyArgon2(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[2] != "v=19" {
+		return false
+	}
+	var memory, passes uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil || passes == 0 || threads == 0 {
+		return false
+	}
+	salt, err1 := base64.RawStdEncoding.DecodeString(parts[4])
+	want, err2 := base64.RawStdEncoding.DecodeString(parts[5])
+	if err1 != nil || err2 != nil || len(want) == 0 {
+		return false
+	}
+	var got []byte
+	if parts[1] == "argon2id" {
+		got = argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(len(want)))
+	} else {
+		got = argon2.Key([]byte(password), salt, passes, memory, threads, uint32(len(want)))
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// ab64Encode is passlib's base64 variant: no padding, "." instead of "+".
+func ab64Encode(b []byte) string {
+	return strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(b), "+", ".")
+}
+
+// ab64Decode decodes passlib's base64 variant.
+func ab64Decode(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(s, ".", "+"))
+}
+
+// LoadPasswordFile reads "user:hash" lines in htpasswd style. Blank lines
+// and lines starting with # are ignored.
+func LoadPasswordFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		user, hash, ok := strings.Cut(text, ":")
+		if !ok || user == "" || hash == "" {
+			return nil, errors.New("bolt: " + path + ":" + strconv.Itoa(line) + ": expected user:hash")
+		}
+		users[user] = hash
+	}
+	return users, scanner.Err()
+}
@@ -0,0 +1,247 @@
+package bolt
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DigestAuthConfig configures the DigestAuth middleware.
+type DigestAuthConfig struct {
+	Realm     string            // Protection space, part of every HA1
+	Users     map[string]string // User name to hex HA1, see DigestHA1
+	Algorithm string            // "MD5" (default, for older clients) or "SHA-256"
+	NonceTTL  time.Duration     // Nonce lifetime, 0 for five minutes
+}
+
+// DigestHA1 returns the hex HA1 value H(user:realm:password) to store in
+// DigestAuthConfig.Users, so plain passwords need not be kept.
+func DigestHA1(algorithm, user, realm, password string) string {
+	h := digestHash(algorithm)
+	h.Write([]byte(user + ":" + realm + ":" + password))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// digestHash returns the hash function of a Digest algorithm.
+func digestHash(algorithm string) hash.Hash {
+	if strings.EqualFold(algorithm, "SHA-256") {
+		return sha256.New()
+	}
+	return md5.New()
+}
+
+// DigestAuth returns middleware for HTTP Digest authentication (RFC 7616,
+// qop=auth) for legacy clients that cannot use Basic over TLS. Nonces are
+// HMACs with a limited lifetime; expired ones are answered with stale=true so
+// clients retry transparently. The nonce count of every nonce in use is
+// remembered until it expires, and a request whose nc does not increase is
+// rejected as a replay.
+func DigestAuth(config DigestAuthConfig) Middleware {
+	if config.Realm == "" {
+		config.Realm = "Restricted"
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = "MD5"
+	}
+	if config.NonceTTL <= 0 {
+		config.NonceTTL = 5 * time.Minute
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	d := &digestAuth{
+		config: config,
+		secret: secret,
+		counts: make(map[string]nonceCount),
+		// Unknown users are checked against this, so they take as long
+		// as wrong passwords and names cannot be probed by timing.
+		dummyHA1: DigestHA1(config.Algorithm, "", config.Realm, hex.EncodeToString(secret)),
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			user, stale, ok := d.verify(c)
+			if !ok {
+				c.headers.Set("WWW-Authenticate", d.challenge(stale))
+				return ErrUnauthorized
+			}
+			c.Set(PrincipalKey, &Principal{Name: user, Scheme: "digest"})
+			return next(c)
+		}
+	}
+}
+
+// DigestAuth protects the current route or group with HTTP Digest
+// authentication and documents it in the OpenAPI document.
+func (cl *ChainLink) DigestAuth(config DigestAuthConfig) *ChainLink {
+	return cl.withAuth(DigestAuth(config), SecurityDigest, SecurityScheme{Type: "http", Scheme: "digest"})
+}
+
+// digestAuth holds the state of one DigestAuth middleware
+type digestAuth struct {
+	config   DigestAuthConfig
+	secret   []byte
+	dummyHA1 string // Compared for unknown users
+
+	mu     sync.Mutex
+	counts map[string]nonceCount // Highest nc seen per nonce
+	swept  time.Time             // Last removal of expired nonces
+}
+
+// nonceCount is the highest nonce count accepted for a nonce
+type nonceCount struct {
+	nc      uint64
+	expires time.Time
+}
+
+// challenge builds the WWW-Authenticate value with a fresh nonce.
+func (d *digestAuth) challenge(stale bool) string {
+	v := `Digest realm="` + d.config.Realm + `", qop="auth", algorithm=` + d.config.Algorithm +
+		`, nonce="` + d.nonce(time.Now()) + `"`
+	if stale {
+		v += ", stale=true"
+	}
+	return v
+}
+
+// nonce encodes the issue time with an HMAC over it.
+func (d *digestAuth) nonce(t time.Time) string {
+	var buf [8 + sha256.Size]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(t.UnixNano()))
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write(buf[:8])
+	copy(buf[8:], mac.Sum(nil))
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+// checkNonce reports whether nonce was issued by us and when it expires.
+func (d *digestAuth) checkNonce(nonce string) (valid bool, expires time.Time) {
+	raw, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(raw) != 8+sha256.Size {
+		return false, time.Time{}
+	}
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write(raw[:8])
+	if !hmac.Equal(mac.Sum(nil), raw[8:]) {
+		return false, time.Time{}
+	}
+	issued := time.Unix(0, int64(binary.BigEndian.Uint64(raw[:8])))
+	return true, issued.Add(d.config.NonceTTL)
+}
+
+// useCount records nc for nonce and reports whether it is higher than every
+// count seen before, so a captured Authorization header cannot be replayed.
+func (d *digestAuth) useCount(nonce string, nc uint64, expires time.Time) bool {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if now.Sub(d.swept) >= d.config.NonceTTL {
+		for n, e := range d.counts {
+			if now.After(e.expires) {
+				delete(d.counts, n)
+			}
+		}
+		d.swept = now
+	}
+	if last, ok := d.counts[nonce]; ok && nc <= last.nc {
+		return false
+	}
+	d.counts[nonce] = nonceCount{nc: nc, expires: expires}
+	return true
+}
+
+// verify checks the Digest Authorization header. stale is set when the
+// credentials were right but the nonce has expired.
+func (d *digestAuth) verify(c *Context) (user string, stale, ok bool) {
+	auth := c.Request.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "digest ") {
+		return "", false, false
+	}
+	p := parseAuthParams(auth[7:])
+	user = p["username"]
+	ha1, known := d.config.Users[user]
+	if !known {
+		ha1 = d.dummyHA1
+	}
+	if p["realm"] != d.config.Realm || p["qop"] != "auth" ||
+		p["uri"] != c.Request.RequestURI || p["nc"] == "" || p["cnonce"] == "" {
+		return "", false, false
+	}
+	if alg := p["algorithm"]; alg != "" && !strings.EqualFold(alg, d.config.Algorithm) {
+		return "", false, false
+	}
+	valid, expires := d.checkNonce(p["nonce"])
+	if !valid {
+		return "", false, false
+	}
+	nc, err := strconv.ParseUint(p["nc"], 16, 64)
+	if err != nil || nc == 0 {
+		return "", false, false
+	}
+
+	h := digestHash(d.config.Algorithm)
+	h.Write([]byte(c.Request.Method + ":" + p["uri"]))
+	ha2 := hex.EncodeToString(h.Sum(nil))
+	h.Reset()
+	h.Write([]byte(ha1 + ":" + p["nonce"] + ":" + p["nc"] + ":" + p["cnonce"] + ":auth:" + ha2))
+	expected := hex.EncodeToString(h.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(p["response"]))) != 1 || !known {
+		return "", false, false
+	}
+	if time.Now().After(expires) {
+		return "", true, false
+	}
+	if !d.useCount(p["nonce"], nc, expires) {
+		return "", false, false
+	}
+	return user, false, true
+}
+
+// parseAuthParams parses comma-separated auth-params with optionally
+// quoted values, as used by Digest credentials.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string, 10)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
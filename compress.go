@@ -0,0 +1,473 @@
+package bolt
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressWriter is a resettable streaming compressor. *gzip.Writer,
+// *zlib.Writer, *zstd.Encoder and *brotli.Writer satisfy it.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressEncoder describes one Content-Encoding. Writers are pooled per
+// encoder, so NewWriter is only called when the pool is empty.
+type CompressEncoder struct {
+	Name      string                                               // Content-Encoding token
+	NewWriter func(w io.Writer, level int) (CompressWriter, error) // level 0 means the encoder default
+	NewReader func(r io.Reader) (io.ReadCloser, error)             // Optional, used for request bodies
+}
+
+// GzipEncoder returns the gzip encoder.
+func GzipEncoder() CompressEncoder {
+	return CompressEncoder{
+		Name: "gzip",
+		NewWriter: func(w io.Writer, level int) (CompressWriter, error) {
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			return gzip.NewWriterLevel(w, level)
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	}
+}
+
+// DeflateEncoder returns the deflate encoder. HTTP's "deflate" is the zlib
+// format, not raw DEFLATE.
+func DeflateEncoder() CompressEncoder {
+	return CompressEncoder{
+		Name: "deflate",
+		NewWriter: func(w io.Writer, level int) (CompressWriter, error) {
+			if level == 0 {
+				level = zlib.DefaultCompression
+			}
+			return zlib.NewWriterLevel(w, level)
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	}
+}
+
+// zstdWindowSize caps the zstd window at the 8 MiB that browsers accept for
+// the "zstd" Content-Encoding (RFC 9659).
+const zstdWindowSize = 8 << 20
+
+// ZstdEncoder returns the zstd encoder. Level uses the zstd scale of 1 to 22.
+func ZstdEncoder() CompressEncoder {
+	return CompressEncoder{
+		Name: "zstd",
+		NewWriter: func(w io.Writer, level int) (CompressWriter, error) {
+			opts := []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(zstdWindowSize)}
+			if level != 0 {
+				opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+			}
+			return zstd.NewWriter(w, opts...)
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdWindowSize))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	}
+}
+
+// BrotliEncoder returns the brotli encoder. Level uses the brotli scale of 1
+// to 11 and defaults to 5, which suits on-the-fly compression.
+func BrotliEncoder() CompressEncoder {
+	return CompressEncoder{
+		Name: "br",
+		NewWriter: func(w io.Writer, level int) (CompressWriter, error) {
+			if level == 0 {
+				level = 5
+			}
+			return brotli.NewWriterLevel(w, level), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(brotli.NewReader(r)), nil
+		},
+	}
+}
+
+// CompressConfig configures the Compress middleware.
+type CompressConfig struct {
+	Encoders          []CompressEncoder // In server preference order
+	Level             int               // Passed to every encoder, 0 for defaults
+	MinSize           int               // Smaller responses are sent as is
+	ContentTypes      []string          // Media types to compress, "type/*" matches a whole type; nil for the defaults
+	DecompressRequest bool              // Decode compressed request bodies
+}
+
+// DefaultCompressConfig returns the default compression configuration.
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		Encoders: []CompressEncoder{ZstdEncoder(), BrotliEncoder(), GzipEncoder(), DeflateEncoder()},
+		MinSize:  1024,
+		ContentTypes: []string{
+			"text/*",
+			"application/json",
+			"application/x-ndjson",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"application/wasm",
+			"image/svg+xml",
+		},
+	}
+}
+
+// compressor holds the pools and settings shared by all requests
+type compressor struct {
+	config   CompressConfig
+	encoders []*pooledEncoder
+	exact    map[string]bool
+	prefixes []string
+}
+
+// pooledEncoder is an encoder with its writer pool
+type pooledEncoder struct {
+	CompressEncoder
+	level int
+	pool  sync.Pool
+}
+
+// get returns a writer reset to w.
+func (e *pooledEncoder) get(w io.Writer) (CompressWriter, error) {
+	if cw, ok := e.pool.Get().(CompressWriter); ok {
+		cw.Reset(w)
+		return cw, nil
+	}
+	return e.NewWriter(w, e.level)
+}
+
+// Compress returns middleware that compresses responses according to the
+// request's Accept-Encoding. Responses that already have a Content-Encoding,
+// partial content, non-matching content types and bodies under MinSize are
+// sent unchanged. Flushes are passed through, so SSE and NDJSON streams are
+// compressed incrementally.
+func Compress(config ...CompressConfig) Middleware {
+	cfg := DefaultCompressConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.Encoders == nil {
+			cfg.Encoders = DefaultCompressConfig().Encoders
+		}
+		if cfg.ContentTypes == nil {
+			cfg.ContentTypes = DefaultCompressConfig().ContentTypes
+		}
+	}
+
+	cp := &compressor{config: cfg, exact: make(map[string]bool)}
+	for _, enc := range cfg.Encoders {
+		cp.encoders = append(cp.encoders, &pooledEncoder{CompressEncoder: enc, level: cfg.Level})
+	}
+	for _, ct := range cfg.ContentTypes {
+		ct = strings.ToLower(ct)
+		if prefix, ok := strings.CutSuffix(ct, "/*"); ok {
+			cp.prefixes = append(cp.prefixes, prefix+"/")
+		} else {
+			cp.exact[ct] = true
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if cfg.DecompressRequest {
+				if err := cp.decodeRequest(c); err != nil {
+					return err
+				}
+			}
+
+			enc := cp.negotiate(c.Request.Header.Get("Accept-Encoding"))
+			if enc == nil || c.Request.Method == http.MethodHead {
+				if enc == nil && len(cp.encoders) > 0 {
+					addVary(c.headers, "Accept-Encoding")
+				}
+				return next(c)
+			}
+
+			orig := c.Response
+			cw := &compressResponseWriter{ResponseWriter: orig, header: c.headers, cp: cp, enc: enc}
+			c.Response = cw
+			err := next(c)
+			c.Response = orig
+			if cerr := cw.close(); err == nil {
+				err = cerr
+			}
+			return err
+		}
+	}
+}
+
+// negotiate picks the encoder with the highest q-value, preferring earlier
+// encoders on ties. It returns nil when identity should be used.
+func (cp *compressor) negotiate(accept string) *pooledEncoder {
+	if accept == "" {
+		return nil
+	}
+	var best *pooledEncoder
+	bestQ := 0.0
+	for _, enc := range cp.encoders {
+		if q := acceptQuality(accept, enc.Name); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the q-value an Accept-Encoding header gives token,
+// falling back to "*" when the token is not listed.
+func acceptQuality(header, token string) float64 {
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if strings.EqualFold(name, token) {
+			return q
+		}
+		if name == "*" {
+			wildcard = q
+		}
+	}
+	return max(wildcard, 0)
+}
+
+// addVary adds value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, line := range h.Values("Vary") {
+		for _, v := range strings.Split(line, ",") {
+			if v = strings.TrimSpace(v); v == "*" || strings.EqualFold(v, value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// compressible reports whether the content type is in the allowlist.
+func (cp *compressor) compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if cp.exact[mt] {
+		return true
+	}
+	for _, prefix := range cp.prefixes {
+		if strings.HasPrefix(mt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeRequest replaces a compressed request body with a decoding reader.
+// The body limit of BindJSON and DecodeStream applies to the decoded bytes.
+func (cp *compressor) decodeRequest(c *Context) error {
+	ce := c.Request.Header.Get("Content-Encoding")
+	if ce == "" || strings.EqualFold(ce, "identity") || c.Request.Body == nil {
+		return nil
+	}
+	for _, enc := range cp.encoders {
+		if !strings.EqualFold(enc.Name, ce) || enc.NewReader == nil {
+			continue
+		}
+		r, err := enc.NewReader(c.Request.Body)
+		if err != nil {
+			return ErrBadRequest
+		}
+		c.Request.Body = &decodedBody{ReadCloser: r, orig: c.Request.Body}
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+		return nil
+	}
+	return ErrUnsupportedEncoding
+}
+
+// decodedBody closes both the decoder and the original body
+type decodedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
+
+// Close closes the decoder and the underlying request body
+func (b *decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.orig.Close()
+}
+
+// compressResponseWriter buffers the start of the body until it knows
+// whether the response should be compressed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	cp          *compressor
+	enc         *pooledEncoder
+	w           CompressWriter // Set once compression has started
+	buf         []byte
+	status      int
+	decided     bool
+	compressing bool
+	err         error
+}
+
+// WriteHeader records the status. Headers are sent once the first body
+// bytes decide whether to compress.
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status = code
+	// Decide now when the size is known or there will be no body.
+	if code == http.StatusNoContent || code == http.StatusNotModified ||
+		code == http.StatusSwitchingProtocols || cw.header.Get("Content-Length") != "" {
+		cw.decide(false)
+	}
+}
+
+// Write buffers up to MinSize bytes, then streams through the encoder.
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) >= cw.cp.config.MinSize {
+			cw.decide(false)
+			if cw.err != nil {
+				return 0, cw.err
+			}
+		}
+		return len(p), nil
+	}
+	if cw.compressing {
+		return cw.w.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends buffered bytes through the encoder and flushes the connection.
+// A flush before MinSize is reached starts compression anyway, since the
+// response is being streamed.
+func (cw *compressResponseWriter) Flush() {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if cw.compressing {
+		cw.w.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the headers, starts the encoder if the response qualifies and
+// writes any buffered bytes.
+func (cw *compressResponseWriter) decide(streaming bool) {
+	cw.decided = true
+	h := cw.header
+
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	eligible := cw.status == http.StatusOK || (cw.status > 200 && cw.status < 300 &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusPartialContent)
+	eligible = eligible && h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" &&
+		cw.cp.compressible(h.Get("Content-Type"))
+	if eligible {
+		addVary(h, "Accept-Encoding")
+	}
+	if eligible && !streaming {
+		size := len(cw.buf)
+		if cl := h.Get("Content-Length"); cl != "" {
+			size, _ = strconv.Atoi(cl)
+		}
+		eligible = size >= cw.cp.config.MinSize
+	}
+
+	if eligible {
+		w, err := cw.enc.get(cw.ResponseWriter)
+		if err != nil {
+			cw.err = err
+			eligible = false
+		} else {
+			cw.w = w
+			cw.compressing = true
+			h.Set("Content-Encoding", cw.enc.Name)
+			h.Del("Content-Length")
+			h.Del("Accept-Ranges")
+			// The encoded body differs byte for byte, so a strong ETag becomes weak.
+			if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				h.Set("ETag", "W/"+etag)
+			}
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return
+	}
+	if cw.compressing {
+		_, cw.err = cw.w.Write(cw.buf)
+	} else {
+		_, cw.err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+}
+
+// close finishes the response and returns the encoder to its pool.
+func (cw *compressResponseWriter) close() error {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			// Nothing was written; leave the response to the ErrorHandler.
+			return nil
+		}
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(false)
+	}
+	if !cw.compressing {
+		return cw.err
+	}
+	err := cw.w.Close()
+	cw.enc.pool.Put(cw.w)
+	cw.w = nil
+	if cw.err != nil {
+		return cw.err
+	}
+	return err
+}
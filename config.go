@@ -1,6 +1,9 @@
 package bolt
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
@@ -13,6 +16,14 @@ func DefaultConfig() Config {
 		MaxPoolSize:       1000,
 		PreallocateRoutes: 100,
 		DevMode:           false,
+		MaxBodySize:       10 << 20, // 10MB
+		Multipart: MultipartConfig{
+			MaxMemory:      32 << 20, // 32MB
+			MaxFileSize:    0,
+			MaxRequestSize: 64 << 20, // 64MB
+			TempFiles:      TempFilesAllow,
+		},
+		WebSocket: DefaultWebSocketConfig(),
 		DocsConfig: DocsConfig{
 			Enabled:     true,
 			SpecPath:    "/openapi.json",
@@ -78,3 +89,75 @@ func WithCustomDocGenerator(gen DocGenerator) Option {
 		c.DocsConfig.Generator = gen
 	}
 }
+
+// WithMultipartLimits sets form and upload size limits
+func WithMultipartLimits(maxMemory, maxFileSize, maxRequestSize int64) Option {
+	return func(c *Config) {
+		c.Multipart.MaxMemory = maxMemory
+		c.Multipart.MaxFileSize = maxFileSize
+		c.Multipart.MaxRequestSize = maxRequestSize
+	}
+}
+
+// WithTempFilePolicy controls whether large uploads may spill to disk
+func WithTempFilePolicy(policy TempFilePolicy) Option {
+	return func(c *Config) {
+		c.Multipart.TempFiles = policy
+	}
+}
+
+// WithKeyring sets the keyring used for signed and encrypted cookies
+func WithKeyring(kr *Keyring) Option {
+	return func(c *Config) {
+		c.Keyring = kr
+	}
+}
+
+// WithHandlerTimeout applies a Timeout to every handler. Routes registered
+// with App.WebSocket or App.SSE are exempt, since their connections outlive
+// any handler deadline; NDJSON and other streamed responses are buffered
+// until the handler returns.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.HandlerTimeout = d
+	}
+}
+
+// WithWebSocketConfig sets the WebSocket upgrade configuration
+func WithWebSocketConfig(ws WebSocketConfig) Option {
+	return func(c *Config) {
+		c.WebSocket = ws
+	}
+}
+
+// WithBodyLimit sets the default request body limit for JSON decoding
+func WithBodyLimit(n int64) Option {
+	return func(c *Config) {
+		c.MaxBodySize = n
+	}
+}
+
+// WithTrustedProxies sets the proxies whose forwarding header is used by
+// RealIP, Scheme and Host
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(c *Config) {
+		c.TrustedProxies = cidrs
+	}
+}
+
+// WithProxyHeader sets the forwarding header the trusted proxies set. It
+// must match the proxy configuration: the other headers are never read, so
+// a client cannot inject them past a proxy that does not strip them.
+func WithProxyHeader(h ProxyHeader) Option {
+	return func(c *Config) {
+		c.ProxyHeader = h
+	}
+}
+
+// WithLogger sets the logger used by the framework, and by AccessLog when
+// its config sets neither Logger nor Output
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = l
+	}
+}
@@ -1,7 +1,6 @@
 package bolt
 
 import (
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -61,8 +60,16 @@ type Context struct {
 	params     ParamMap
 	query      QueryValues
 	StatusCode StatusCode
-	headers    http.Header // Cached response headers
-	fields     []Field     // Pre-allocated field slice for Fast API (reused via pool)
+	headers    http.Header    // Cached response headers
+	fields     []Field        // Pre-allocated field slice for Fast API (reused via pool)
+	session    *sessionState  // Set by the Session middleware
+	locals     []local        // Request-scoped values set by Set (reused via pool)
+	refs       int32          // Extra holders, e.g. a handler abandoned by Timeout
+	writer     responseWriter // Tracks status and size, embedded to stay pooled
+	bodyLimit  int64          // Per-route override of Config.MaxBodySize
+	route      string         // Matched route pattern, set by the router
+	serverReq  *Request       // The request net/http passed in, c.Request may be a copy
+	streaming  bool           // The route was registered by App.WebSocket or App.SSE
 }
 
 // Param gets a URL parameter by key
@@ -232,16 +239,17 @@ func (c *Context) Bytes(status StatusCode, contentType ContentType, data []byte)
 }
 
 // BindJSON binds request body to a struct using optimized JSON decoding.
+// Bodies over the app or route body limit return ErrRequestTooLarge.
 func (c *Context) BindJSON(v interface{}) error {
 	if c.Request.Body == nil {
 		return ErrBadRequest
 	}
 
 	// Use streaming decoder with limited reader
-	lr := io.LimitReader(c.Request.Body, 10<<20) // 10MB limit
-	decoder := json.NewDecoder(lr)
+	body := c.limitedBody()
+	decoder := json.NewDecoder(body)
 	if err := decoder.Decode(v); err != nil {
-		return ErrBadRequest
+		return body.mapError(err)
 	}
 	return nil
 }
@@ -383,4 +391,3 @@ func (c *Context) InternalServerError(fields ...Field) error {
 func (c *Context) Text(status int, b []byte) error {
 	return c.StringBytes(status, b)
 }
-
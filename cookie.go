@@ -0,0 +1,264 @@
+package bolt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// minKeySize is the minimum length of a keyring secret.
+const minKeySize = 32
+
+// Keyring holds the secrets used to sign and encrypt cookies.
+// The first secret signs and encrypts, every secret is tried when verifying,
+// so keys can be rotated by prepending a new one.
+type Keyring struct {
+	keys []cookieKey
+}
+
+// cookieKey holds the sub-keys derived from one keyring secret
+type cookieKey struct {
+	sign []byte
+	aead cipher.AEAD
+}
+
+// NewKeyring creates a keyring from one or more secrets of at least 32 bytes.
+func NewKeyring(secrets ...[]byte) (*Keyring, error) {
+	if len(secrets) == 0 {
+		return nil, ErrInvalidKey
+	}
+	kr := &Keyring{keys: make([]cookieKey, 0, len(secrets))}
+	for _, secret := range secrets {
+		if len(secret) < minKeySize {
+			return nil, ErrInvalidKey
+		}
+		block, err := aes.NewCipher(deriveKey(secret, "bolt-cookie-encrypt"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		kr.keys = append(kr.keys, cookieKey{
+			sign: deriveKey(secret, "bolt-cookie-sign"),
+			aead: aead,
+		})
+	}
+	return kr, nil
+}
+
+// deriveKey derives an independent 32-byte key for the given purpose.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// cookieMAC authenticates a value bound to its cookie name.
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'='})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+var cookieEncoding = base64.RawURLEncoding
+
+// Sign returns value with an HMAC-SHA256 signature bound to name.
+func (kr *Keyring) Sign(name, value string) string {
+	encoded := cookieEncoding.EncodeToString([]byte(value))
+	sig := cookieMAC(kr.keys[0].sign, name, encoded)
+	return encoded + "." + cookieEncoding.EncodeToString(sig)
+}
+
+// Verify checks a signed value against every key and returns the original value.
+func (kr *Keyring) Verify(name, signed string) (string, error) {
+	encoded, sigPart, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	sig, err := cookieEncoding.DecodeString(sigPart)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, k := range kr.keys {
+		if hmac.Equal(sig, cookieMAC(k.sign, name, encoded)) {
+			value, err := cookieEncoding.DecodeString(encoded)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// Encrypt seals value with AES-GCM using name as additional data.
+func (kr *Keyring) Encrypt(name, value string) (string, error) {
+	aead := kr.keys[0].aead
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return cookieEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt, trying every key.
+func (kr *Keyring) Decrypt(name, encrypted string) (string, error) {
+	data, err := cookieEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, k := range kr.keys {
+		ns := k.aead.NonceSize()
+		if len(data) < ns+k.aead.Overhead() {
+			return "", ErrInvalidCookie
+		}
+		plain, err := k.aead.Open(nil, data[:ns], data[ns:], []byte(name))
+		if err == nil {
+			return string(plain), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// CookieOption customizes a cookie set through the Context helpers
+type CookieOption func(*http.Cookie)
+
+// CookiePath sets the cookie path
+func CookiePath(path string) CookieOption {
+	return func(ck *http.Cookie) { ck.Path = path }
+}
+
+// CookieDomain sets the cookie domain
+func CookieDomain(domain string) CookieOption {
+	return func(ck *http.Cookie) { ck.Domain = domain }
+}
+
+// CookieMaxAge sets the cookie lifetime
+func CookieMaxAge(d time.Duration) CookieOption {
+	return func(ck *http.Cookie) {
+		ck.MaxAge = int(d / time.Second)
+		ck.Expires = time.Now().Add(d)
+	}
+}
+
+// CookieSameSite sets the SameSite attribute
+func CookieSameSite(mode http.SameSite) CookieOption {
+	return func(ck *http.Cookie) { ck.SameSite = mode }
+}
+
+// CookieSecure overrides the Secure attribute
+func CookieSecure(secure bool) CookieOption {
+	return func(ck *http.Cookie) { ck.Secure = secure }
+}
+
+// CookieHTTPOnly overrides the HttpOnly attribute
+func CookieHTTPOnly(httpOnly bool) CookieOption {
+	return func(ck *http.Cookie) { ck.HttpOnly = httpOnly }
+}
+
+// Cookie returns the value of a request cookie, or "" if it is not present.
+func (c *Context) Cookie(name string) string {
+	ck, err := c.Request.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return ck.Value
+}
+
+// SetCookie adds a Set-Cookie header with secure defaults: HttpOnly,
+// SameSite=Lax, Path=/ and Secure outside of dev mode.
+func (c *Context) SetCookie(name, value string, opts ...CookieOption) {
+	ck := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   !c.app.config.DevMode,
+	}
+	for _, opt := range opts {
+		opt(ck)
+	}
+	if v := ck.String(); v != "" {
+		c.headers.Add("Set-Cookie", v)
+	}
+}
+
+// ClearCookie expires a cookie on the client.
+func (c *Context) ClearCookie(name string, opts ...CookieOption) {
+	opts = append(opts, func(ck *http.Cookie) {
+		ck.MaxAge = -1
+		ck.Expires = time.Unix(0, 0)
+	})
+	c.SetCookie(name, "", opts...)
+}
+
+// keyring returns the configured keyring or ErrInvalidKey if none is set.
+func (c *Context) keyring() (*Keyring, error) {
+	if c.app.config.Keyring == nil {
+		return nil, ErrInvalidKey
+	}
+	return c.app.config.Keyring, nil
+}
+
+// SignedCookie returns the value of a cookie set by SetSignedCookie.
+// Tampered cookies return ErrInvalidCookie.
+func (c *Context) SignedCookie(name string) (string, error) {
+	kr, err := c.keyring()
+	if err != nil {
+		return "", err
+	}
+	ck, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return kr.Verify(name, ck.Value)
+}
+
+// SetSignedCookie sets a cookie whose value is signed with the keyring.
+func (c *Context) SetSignedCookie(name, value string, opts ...CookieOption) error {
+	kr, err := c.keyring()
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, kr.Sign(name, value), opts...)
+	return nil
+}
+
+// EncryptedCookie returns the decrypted value of a cookie set by SetEncryptedCookie.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	kr, err := c.keyring()
+	if err != nil {
+		return "", err
+	}
+	ck, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return kr.Decrypt(name, ck.Value)
+}
+
+// SetEncryptedCookie sets a cookie whose value is encrypted with the keyring.
+func (c *Context) SetEncryptedCookie(name, value string, opts ...CookieOption) error {
+	kr, err := c.keyring()
+	if err != nil {
+		return err
+	}
+	encrypted, err := kr.Encrypt(name, value)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, encrypted, opts...)
+	return nil
+}
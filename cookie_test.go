@@ -0,0 +1,151 @@
+package bolt
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	oldSecret = bytes.Repeat([]byte("o"), 32)
+	newSecret = bytes.Repeat([]byte("n"), 32)
+)
+
+func newTestKeyring(t *testing.T, secrets ...[]byte) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring(secrets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestNewKeyring(t *testing.T) {
+	if _, err := NewKeyring(); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("no secrets: err = %v, want ErrInvalidKey", err)
+	}
+	if _, err := NewKeyring(newSecret, []byte("short")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("short secret: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestKeyringVerify(t *testing.T) {
+	kr := newTestKeyring(t, oldSecret)
+	rotated := newTestKeyring(t, newSecret, oldSecret)
+	signed := kr.Sign("user", "alice")
+	encoded, sig, _ := strings.Cut(signed, ".")
+	other, _, _ := strings.Cut(kr.Sign("user", "bob"), ".")
+
+	tests := []struct {
+		name   string
+		kr     *Keyring
+		cookie string
+		signed string
+		want   string
+		err    error
+	}{
+		{"valid", kr, "user", signed, "alice", nil},
+		{"rotated key", rotated, "user", signed, "alice", nil},
+		{"signed with the new key", rotated, "user", rotated.Sign("user", "bob"), "bob", nil},
+		{"tampered value", kr, "user", tamper(encoded) + "." + sig, "", ErrInvalidCookie},
+		{"value of another user", kr, "user", other + "." + sig, "", ErrInvalidCookie},
+		{"tampered signature", kr, "user", encoded + "." + tamper(sig), "", ErrInvalidCookie},
+		{"other cookie name", kr, "role", signed, "", ErrInvalidCookie},
+		{"unknown key", newTestKeyring(t, newSecret), "user", signed, "", ErrInvalidCookie},
+		{"unsigned", kr, "user", encoded, "", ErrInvalidCookie},
+		{"bad signature encoding", kr, "user", encoded + ".!!", "", ErrInvalidCookie},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.kr.Verify(tt.cookie, tt.signed)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Fatalf("Verify = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestKeyringDecrypt(t *testing.T) {
+	kr := newTestKeyring(t, oldSecret)
+	sealed, err := kr.Encrypt("user", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := kr.Encrypt("user", "alice"); again == sealed {
+		t.Error("encryption is deterministic")
+	}
+
+	tests := []struct {
+		name   string
+		kr     *Keyring
+		cookie string
+		sealed string
+		want   string
+		err    error
+	}{
+		{"valid", kr, "user", sealed, "alice", nil},
+		{"rotated key", newTestKeyring(t, newSecret, oldSecret), "user", sealed, "alice", nil},
+		{"tampered", kr, "user", tamper(sealed), "", ErrInvalidCookie},
+		{"truncated", kr, "user", sealed[:10], "", ErrInvalidCookie},
+		{"other cookie name", kr, "role", sealed, "", ErrInvalidCookie},
+		{"unknown key", newTestKeyring(t, newSecret), "user", sealed, "", ErrInvalidCookie},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.kr.Decrypt(tt.cookie, tt.sealed)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Fatalf("Decrypt = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestSignedCookieRoundTrip(t *testing.T) {
+	app := New(WithKeyring(newTestKeyring(t, newSecret)))
+	app.Get("/set", func(c *Context) error {
+		if err := c.SetSignedCookie("user", "alice"); err != nil {
+			return err
+		}
+		return c.NoContent()
+	})
+	app.Get("/get", func(c *Context) error {
+		v, err := c.SignedCookie("user")
+		if err != nil {
+			return ErrUnauthorized
+		}
+		return c.String(http.StatusOK, v)
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/set", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %v, want one", cookies)
+	}
+	ck := cookies[0]
+	if !ck.HttpOnly || !ck.Secure || ck.SameSite != http.SameSiteLaxMode || ck.Path != "/" {
+		t.Errorf("cookie = %+v, want HttpOnly, Secure, SameSite=Lax and Path=/", ck)
+	}
+
+	tampered := *ck
+	tampered.Value = tamper(ck.Value)
+	for _, tt := range []struct {
+		name   string
+		cookie *http.Cookie
+		status int
+	}{
+		{"original", ck, http.StatusOK},
+		{"tampered", &tampered, http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/get", nil)
+		req.AddCookie(tt.cookie)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package bolt
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware. An origin is allowed when it
+// matches any of AllowOrigins, AllowOriginPatterns or AllowOriginFunc.
+type CORSConfig struct {
+	AllowOrigins        []string          // Exact origins, "*" for any, or "https://*.example.com" for subdomains
+	AllowOriginPatterns []*regexp.Regexp  // Must match the whole Origin value
+	AllowOriginFunc     func(string) bool // Custom origin check
+	AllowMethods        []string          // Defaults to the common REST methods
+	AllowHeaders        []string          // Empty reflects Access-Control-Request-Headers
+	ExposeHeaders       []string          // Response headers readable by scripts
+	AllowCredentials    bool              // Send Access-Control-Allow-Credentials; panics with "*" origins
+	MaxAge              time.Duration     // How long browsers may cache a preflight, 0 to omit
+	AllowPrivateNetwork bool              // Answer Private Network Access preflights
+}
+
+// DefaultCORSConfig returns a permissive configuration for public APIs.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		MaxAge:       10 * time.Minute,
+	}
+}
+
+// corsPolicy is a CORSConfig with its header values prepared once
+type corsPolicy struct {
+	config        CORSConfig
+	anyOrigin     bool
+	exact         map[string]bool
+	subdomains    []originSuffix
+	patterns      []*regexp.Regexp // AllowOriginPatterns anchored to the whole origin
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// originSuffix matches "scheme://*.domain" origins
+type originSuffix struct {
+	scheme string // Including "://"
+	suffix string // Including the leading dot
+}
+
+// CORS returns middleware that applies a cross-origin resource sharing policy.
+// Preflight requests are answered directly, also for paths that only have
+// routes for other methods. Attach it with Use, or per group or route with
+// ChainLink.CORS. Installed with Use it runs in Use order, so add it before
+// any authentication middleware; route and group policies answer preflights
+// ahead of the app middleware.
+func CORS(config ...CORSConfig) Middleware {
+	cfg := DefaultCORSConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if len(cfg.AllowMethods) == 0 {
+			cfg.AllowMethods = DefaultCORSConfig().AllowMethods
+		}
+	}
+
+	p := &corsPolicy{
+		config:        cfg,
+		exact:         make(map[string]bool, len(cfg.AllowOrigins)),
+		allowMethods:  strings.Join(cfg.AllowMethods, ", "),
+		allowHeaders:  strings.Join(cfg.AllowHeaders, ", "),
+		exposeHeaders: strings.Join(cfg.ExposeHeaders, ", "),
+	}
+	// Anchor the patterns so "https://app\.example\.com" cannot match
+	// "https://app.example.com.evil.net".
+	p.patterns = make([]*regexp.Regexp, len(cfg.AllowOriginPatterns))
+	for i, re := range cfg.AllowOriginPatterns {
+		p.patterns[i] = regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	}
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, rest, _ := strings.Cut(origin, "*")
+			p.subdomains = append(p.subdomains, originSuffix{scheme: scheme, suffix: rest})
+		default:
+			p.exact[origin] = true
+		}
+	}
+	if p.anyOrigin && cfg.AllowCredentials {
+		// Reflecting every origin with credentials lets any site read
+		// authenticated responses.
+		panic(`bolt: CORS AllowOrigins "*" cannot be combined with AllowCredentials; list the trusted origins`)
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			h := c.headers
+			// The response depends on Origin even when it is absent, so
+			// caches must not serve it to other origins.
+			addVary(h, "Origin")
+			origin := c.Request.Header.Get("Origin")
+			if origin == "" {
+				return next(c)
+			}
+			if c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != "" {
+				return p.preflight(c, origin)
+			}
+			if !p.allowed(origin) {
+				return next(c)
+			}
+			p.setOrigin(h, origin)
+			if p.exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", p.exposeHeaders)
+			}
+			return next(c)
+		}
+	}
+}
+
+// CORS applies a CORS policy to the current route or to every route in the
+// current group, including their automatic preflight responses.
+func (cl *ChainLink) CORS(config ...CORSConfig) *ChainLink {
+	mw := CORS(config...)
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, mw)
+		cl.app.addPreflight(v, mw)
+	case *staticMount:
+		cl.app.rewrapMount(v, mw)
+		v.options.add(MethodGet, mw)
+		v.options.add(MethodHead, mw)
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if r := &cl.app.routes[i]; v.contains(r.Group) {
+				cl.app.rewrapRoute(r, mw)
+				cl.app.addPreflight(r, mw)
+			}
+		}
+	}
+	return cl
+}
+
+// preflight answers a CORS preflight request with 204. Disallowed requests
+// get no CORS headers, which makes the browser reject them.
+func (p *corsPolicy) preflight(c *Context, origin string) error {
+	h := c.headers
+	addVary(h, "Access-Control-Request-Method")
+	addVary(h, "Access-Control-Request-Headers")
+	if p.config.AllowPrivateNetwork {
+		addVary(h, "Access-Control-Request-Private-Network")
+	}
+
+	method := c.Request.Header.Get("Access-Control-Request-Method")
+	if !p.allowed(origin) || !p.methodAllowed(method) {
+		return c.NoContent()
+	}
+
+	p.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", p.allowMethods)
+	if p.allowHeaders != "" {
+		h.Set("Access-Control-Allow-Headers", p.allowHeaders)
+	} else if requested := c.Request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if p.maxAge != "" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	if p.config.AllowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		h.Set("Access-Control-Allow-Private-Network", "true")
+	}
+	return c.NoContent()
+}
+
+// setOrigin sets Access-Control-Allow-Origin and, if enabled, credentials.
+// Credentialed policies never allow "*", so they always name the origin.
+func (p *corsPolicy) setOrigin(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowed reports whether origin passes the policy.
+func (p *corsPolicy) allowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if p.exact[lower] {
+		return true
+	}
+	for _, s := range p.subdomains {
+		if rest, ok := strings.CutPrefix(lower, s.scheme); ok && len(rest) > len(s.suffix) && strings.HasSuffix(rest, s.suffix) {
+			return true
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return p.config.AllowOriginFunc != nil && p.config.AllowOriginFunc(origin)
+}
+
+// methodAllowed reports whether a preflight's requested method is allowed.
+func (p *corsPolicy) methodAllowed(method string) bool {
+	for _, m := range p.config.AllowMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
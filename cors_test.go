@@ -0,0 +1,99 @@
+package bolt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCORSPreflightBeforeAppAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New()
+	app.Use(BasicAuth(BasicAuthConfig{Users: map[string]string{"admin": string(hash)}}))
+	ok := func(c *Context) error { return c.NoContent() }
+	app.Put("/items", ok).CORS(CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowMethods: []string{"PUT"}})
+	app.Delete("/items", ok)
+	app.Get("/plain", ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		wantStatus int
+		wantOrigin string
+	}{
+		{"route policy answers preflight", "OPTIONS", "/items",
+			map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "PUT"},
+			http.StatusNoContent, "https://app.example.com"},
+		{"disallowed origin gets no headers", "OPTIONS", "/items",
+			map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "PUT"},
+			http.StatusNoContent, ""},
+		{"method without policy hits auth", "OPTIONS", "/items",
+			map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+			http.StatusUnauthorized, ""},
+		{"actual request still needs auth", "PUT", "/items",
+			map[string]string{"Origin": "https://app.example.com"},
+			http.StatusUnauthorized, ""},
+		{"path without CORS", "OPTIONS", "/plain",
+			map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"},
+			http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestCORSAutoOptionsWithoutPolicy(t *testing.T) {
+	app := New()
+	app.Get("/plain", func(c *Context) error { return c.NoContent() })
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/plain", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Fatalf("status = %d, Allow = %q, want a plain 404", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestCORSOriginPatternsAnchored(t *testing.T) {
+	app := New()
+	app.Use(CORS(CORSConfig{AllowOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`https://[a-z]+\.example\.com`)}}))
+	app.Get("/", func(c *Context) error { return c.NoContent() })
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://app.example.com.evil.net", false},
+		{"http://https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin") != ""; got != tt.want {
+			t.Errorf("origin %q allowed = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package bolt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CSRFMode selects where the expected CSRF token is kept
+type CSRFMode uint8
+
+const (
+	CSRFDoubleSubmit CSRFMode = iota // Signed cookie, needs a Keyring
+	CSRFSynchronizer                 // Session value, needs the Session middleware
+)
+
+// CSRFConfig configures the CSRF middleware.
+type CSRFConfig struct {
+	Mode           CSRFMode
+	CookieName     string              // Double-submit cookie, defaults to "bolt_csrf"
+	CookieOptions  []CookieOption      // Extra options for the cookie
+	HeaderName     string              // Defaults to "X-CSRF-Token"
+	FormField      string              // Defaults to "csrf_token"
+	TrustedOrigins []string            // Extra origins allowed to post, e.g. "https://admin.example.com"
+	Skip           func(*Context) bool // Requests to exempt, e.g. webhooks
+}
+
+// DefaultCSRFConfig returns the default CSRF configuration.
+func DefaultCSRFConfig() CSRFConfig {
+	return CSRFConfig{
+		Mode:       CSRFDoubleSubmit,
+		CookieName: "bolt_csrf",
+		HeaderName: "X-CSRF-Token",
+		FormField:  "csrf_token",
+	}
+}
+
+// CSRFError is returned when a request fails CSRF validation. It unwraps to
+// ErrForbidden; DefaultErrorHandler answers 403 with the reason.
+type CSRFError struct {
+	Reason string
+}
+
+func (e *CSRFError) Error() string {
+	return "csrf: " + e.Reason
+}
+
+// responseBody is the JSON body DefaultErrorHandler sends for the error.
+func (e *CSRFError) responseBody() []byte {
+	return []byte(`{"error":"Forbidden","reason":"csrf ` + e.Reason + `"}`)
+}
+
+// Unwrap returns ErrForbidden
+func (e *CSRFError) Unwrap() error {
+	return ErrForbidden
+}
+
+// CSRF failure reasons
+var (
+	errCSRFOrigin   = &CSRFError{Reason: "origin not allowed"}
+	errCSRFMissing  = &CSRFError{Reason: "token missing"}
+	errCSRFMismatch = &CSRFError{Reason: "token mismatch"}
+)
+
+const (
+	csrfTokenLen   = 32
+	csrfLocalKey   = "bolt.csrf"
+	csrfSessionKey = "_csrf"
+)
+
+// CSRF returns middleware that protects unsafe methods against cross-site
+// request forgery. It rejects requests whose Origin or Referer names another
+// host, then compares the token from the header or form field with the one
+// kept in a signed cookie or the session. Safe methods pass unchecked.
+// Templates get the token from Context.CSRFToken, which also creates it on
+// first use, so requests that never render a form set no cookie and leave
+// the session untouched.
+func CSRF(config ...CSRFConfig) Middleware {
+	cfg := DefaultCSRFConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		def := DefaultCSRFConfig()
+		if cfg.CookieName == "" {
+			cfg.CookieName = def.CookieName
+		}
+		if cfg.HeaderName == "" {
+			cfg.HeaderName = def.HeaderName
+		}
+		if cfg.FormField == "" {
+			cfg.FormField = def.FormField
+		}
+	}
+	trusted := make(map[string]bool, len(cfg.TrustedOrigins))
+	for _, o := range cfg.TrustedOrigins {
+		trusted[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if cfg.Skip != nil && cfg.Skip(c) {
+				return next(c)
+			}
+			st := &csrfState{config: &cfg}
+			c.Set(csrfLocalKey, st)
+
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				return next(c)
+			}
+
+			if !originAllowed(c.Request, c.Host(), trusted) {
+				return errCSRFOrigin
+			}
+			token, err := cfg.loadToken(c, false)
+			if err != nil {
+				return err
+			}
+			st.token = token
+			sent := c.Request.Header.Get(cfg.HeaderName)
+			if sent == "" {
+				sent = c.FormValue(cfg.FormField)
+			}
+			if sent == "" {
+				return errCSRFMissing
+			}
+			if token == nil || !csrfTokenMatches(sent, token) {
+				return errCSRFMismatch
+			}
+			return next(c)
+		}
+	}
+}
+
+// csrfState is the per-request token, loaded or created on first use
+type csrfState struct {
+	config *CSRFConfig
+	token  []byte
+}
+
+// loadToken returns the expected token. Without one it returns nil, or when
+// create is set, creates and stores a new token.
+func (cfg *CSRFConfig) loadToken(c *Context, create bool) ([]byte, error) {
+	if cfg.Mode == CSRFSynchronizer {
+		s, err := c.Session()
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := s.Get(csrfSessionKey).(string); ok {
+			if token, err := base64.RawURLEncoding.DecodeString(v); err == nil && len(token) == csrfTokenLen {
+				return token, nil
+			}
+		}
+		if !create {
+			return nil, nil
+		}
+		token := newCSRFToken()
+		s.Set(csrfSessionKey, base64.RawURLEncoding.EncodeToString(token))
+		return token, nil
+	}
+
+	if v, err := c.SignedCookie(cfg.CookieName); err == nil {
+		if token, err := base64.RawURLEncoding.DecodeString(v); err == nil && len(token) == csrfTokenLen {
+			return token, nil
+		}
+	} else if err == ErrInvalidKey {
+		return nil, err
+	}
+	if !create {
+		return nil, nil
+	}
+	token := newCSRFToken()
+	if err := c.SetSignedCookie(cfg.CookieName, base64.RawURLEncoding.EncodeToString(token), cfg.CookieOptions...); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// CSRFToken returns the CSRF token to embed in forms or a meta tag. Each call
+// returns a differently masked value of the same token, which keeps it safe
+// from compression side channels like BREACH. The first call creates the
+// token if the client has none; call it before the response is written. It is
+// empty without the CSRF middleware or when the token cannot be stored.
+func (c *Context) CSRFToken() string {
+	st, ok := Local[*csrfState](c, csrfLocalKey)
+	if !ok {
+		return ""
+	}
+	if st.token == nil {
+		token, err := st.config.loadToken(c, true)
+		if err != nil {
+			c.Logger().Error("csrf token not stored", "error", err)
+			return ""
+		}
+		st.token = token
+	}
+	token := st.token
+	masked := make([]byte, 2*csrfTokenLen)
+	pad := masked[:csrfTokenLen]
+	if _, err := rand.Read(pad); err != nil {
+		panic(err)
+	}
+	for i := range token {
+		masked[csrfTokenLen+i] = pad[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// newCSRFToken returns a random token.
+func newCSRFToken() []byte {
+	token := make([]byte, csrfTokenLen)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// csrfTokenMatches compares a submitted, masked token with the expected one.
+func csrfTokenMatches(sent string, token []byte) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil || len(raw) != 2*csrfTokenLen {
+		return false
+	}
+	unmasked := make([]byte, csrfTokenLen)
+	for i := range unmasked {
+		unmasked[i] = raw[i] ^ raw[csrfTokenLen+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, token) == 1
+}
+
+// originAllowed checks Origin, or Referer when Origin is absent, against the
+// requested host and the trusted origins. Requests with neither header rely on
+// the token check alone.
+func originAllowed(r *http.Request, host string, trusted map[string]bool) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		ref := r.Header.Get("Referer")
+		if ref == "" {
+			return true
+		}
+		u, err := url.Parse(ref)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	if origin == "null" {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	if trusted[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, host)
+}
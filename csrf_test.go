@@ -0,0 +1,123 @@
+package bolt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newCSRFApp(t *testing.T) *App {
+	t.Helper()
+	kr, err := NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New(WithKeyring(kr))
+	app.Use(CSRF(CSRFConfig{TrustedOrigins: []string{"https://admin.example.com/"}}))
+	app.Get("/form", func(c *Context) error { return c.String(http.StatusOK, c.CSRFToken()) })
+	app.Get("/plain", func(c *Context) error { return c.NoContent() })
+	app.Post("/submit", func(c *Context) error { return c.NoContent() })
+	return app
+}
+
+// csrfSession renders the form once and returns the masked token and the
+// cookie holding the expected one.
+func csrfSession(t *testing.T, app *App) (string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "bolt_csrf" {
+			return w.Body.String(), ck
+		}
+	}
+	t.Fatal("no CSRF cookie set")
+	return "", nil
+}
+
+// tamper changes the first character of a base64url value, which always
+// changes the decoded bytes.
+func tamper(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+func TestCSRF(t *testing.T) {
+	app := newCSRFApp(t)
+	token, cookie := csrfSession(t, app)
+	otherToken, _ := csrfSession(t, app)
+	tampered := *cookie
+	tampered.Value = tamper(cookie.Value)
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		form   string
+		cookie *http.Cookie
+		status int
+	}{
+		{"safe method", http.MethodGet, nil, "", nil, http.StatusNoContent},
+		{"header token", http.MethodPost, map[string]string{"X-CSRF-Token": token}, "", cookie, http.StatusNoContent},
+		{"form token", http.MethodPost, nil, "csrf_token=" + url.QueryEscape(token), cookie, http.StatusNoContent},
+		{"same origin", http.MethodPost, map[string]string{"X-CSRF-Token": token, "Origin": "http://example.com"}, "", cookie, http.StatusNoContent},
+		{"trusted origin", http.MethodPost, map[string]string{"X-CSRF-Token": token, "Origin": "https://admin.example.com"}, "", cookie, http.StatusNoContent},
+		{"missing token", http.MethodPost, nil, "", cookie, http.StatusForbidden},
+		{"missing cookie", http.MethodPost, map[string]string{"X-CSRF-Token": token}, "", nil, http.StatusForbidden},
+		{"token of another client", http.MethodPost, map[string]string{"X-CSRF-Token": otherToken}, "", cookie, http.StatusForbidden},
+		{"tampered cookie", http.MethodPost, map[string]string{"X-CSRF-Token": token}, "", &tampered, http.StatusForbidden},
+		{"garbage token", http.MethodPost, map[string]string{"X-CSRF-Token": "not-base64!"}, "", cookie, http.StatusForbidden},
+		{"cross origin", http.MethodPost, map[string]string{"X-CSRF-Token": token, "Origin": "https://evil.example"}, "", cookie, http.StatusForbidden},
+		{"cross site referer", http.MethodPost, map[string]string{"X-CSRF-Token": token, "Referer": "https://evil.example/page"}, "", cookie, http.StatusForbidden},
+		{"null origin", http.MethodPost, map[string]string{"X-CSRF-Token": token, "Origin": "null"}, "", cookie, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/submit"
+			if tt.method == http.MethodGet {
+				path = "/plain"
+			}
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.form))
+			if tt.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFTokenMasking(t *testing.T) {
+	app := newCSRFApp(t)
+	first, cookie := csrfSession(t, app)
+
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("existing token was replaced")
+	}
+	if second := w.Body.String(); second == first {
+		t.Error("tokens are not masked per render")
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("cookie set for a request that never rendered the token")
+	}
+}
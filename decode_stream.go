@@ -0,0 +1,211 @@
+package bolt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"iter"
+	"strconv"
+
+	json "github.com/goccy/go-json"
+)
+
+// DecodeError reports an item of a streamed request body that could not be decoded.
+// Decoding continues with the next item after a DecodeError.
+type DecodeError struct {
+	Index int
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return "item " + strconv.Itoa(e.Index) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying decoding error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// BodyLimit returns middleware that overrides the app's MaxBodySize for
+// BindJSON and DecodeStream.
+func BodyLimit(n int64) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			c.bodyLimit = n
+			return next(c)
+		}
+	}
+}
+
+// BodyLimit overrides the request body limit for the current route or group.
+func (cl *ChainLink) BodyLimit(n int64) *ChainLink {
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, BodyLimit(n))
+	case *staticMount:
+		cl.app.rewrapMount(v, BodyLimit(n))
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if v.contains(cl.app.routes[i].Group) {
+				cl.app.rewrapRoute(&cl.app.routes[i], BodyLimit(n))
+			}
+		}
+	}
+	return cl
+}
+
+// limitedReader caps a request body and remembers whether the cap was hit,
+// since JSON decoders do not always surface the underlying read error.
+type limitedReader struct {
+	r        io.Reader
+	n        int64 // Bytes left, negative when unlimited
+	exceeded bool
+}
+
+// Read reads up to the remaining limit and fails with ErrRequestTooLarge past it
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.n < 0 {
+		return lr.r.Read(p)
+	}
+	if lr.n == 0 {
+		// Probe for one more byte to tell an exact fit from an overflow.
+		var one [1]byte
+		if n, err := lr.r.Read(one[:]); n == 0 {
+			return 0, err
+		}
+		lr.exceeded = true
+		return 0, ErrRequestTooLarge
+	}
+	if int64(len(p)) > lr.n {
+		p = p[:lr.n]
+	}
+	n, err := lr.r.Read(p)
+	lr.n -= int64(n)
+	return n, err
+}
+
+// mapError converts a decoding error into ErrRequestTooLarge or ErrBadRequest.
+func (lr *limitedReader) mapError(err error) error {
+	if lr.exceeded {
+		return ErrRequestTooLarge
+	}
+	return bodyError(err)
+}
+
+// limitedBody returns the request body capped at the effective body limit.
+func (c *Context) limitedBody() *limitedReader {
+	limit := c.bodyLimit
+	if limit == 0 {
+		limit = c.app.config.MaxBodySize
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	return &limitedReader{r: c.Request.Body, n: limit}
+}
+
+// DecodeStream iterates over a request body holding either newline-delimited
+// JSON or a top-level JSON array, decoding one item at a time. Items that fail
+// to decode yield a *DecodeError and iteration continues; malformed framing,
+// read errors and ErrRequestTooLarge end the iteration.
+func DecodeStream[T any](c *Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if c.Request.Body == nil {
+			yield(zero, ErrBadRequest)
+			return
+		}
+
+		body := c.limitedBody()
+		br := bufio.NewReader(body)
+		first, err := peekNonSpace(br)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(zero, body.mapError(err))
+			return
+		}
+
+		if first == '[' {
+			decodeArrayStream(br, body, yield)
+			return
+		}
+		decodeNDJSONStream(br, body, yield)
+	}
+}
+
+// peekNonSpace skips leading whitespace and returns the next byte without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// decodeNDJSONStream yields one item per non-empty line.
+func decodeNDJSONStream[T any](br *bufio.Reader, body *limitedReader, yield func(T, error) bool) {
+	for index := 0; ; {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			var zero T
+			yield(zero, body.mapError(err))
+			return
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var v T
+			if uerr := json.Unmarshal(line, &v); uerr != nil {
+				var zero T
+				if !yield(zero, &DecodeError{Index: index, Err: uerr}) {
+					return
+				}
+			} else if !yield(v, nil) {
+				return
+			}
+			index++
+		}
+		if err == io.EOF {
+			return
+		}
+	}
+}
+
+// decodeArrayStream yields the elements of a top-level JSON array.
+// Each element is framed as raw JSON first so type errors stay per item.
+func decodeArrayStream[T any](br *bufio.Reader, body *limitedReader, yield func(T, error) bool) {
+	var zero T
+	dec := json.NewDecoder(br)
+	if _, err := dec.Token(); err != nil { // Opening '['
+		yield(zero, body.mapError(err))
+		return
+	}
+
+	for index := 0; dec.More(); index++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			yield(zero, body.mapError(err))
+			return
+		}
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			if !yield(zero, &DecodeError{Index: index, Err: err}) {
+				return
+			}
+			continue
+		}
+		if !yield(v, nil) {
+			return
+		}
+	}
+
+	if _, err := dec.Token(); err != nil { // Closing ']'
+		yield(zero, body.mapError(err))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -31,12 +32,26 @@ type OpenAPIInfo struct {
 
 // Operation describes a single API operation
 type Operation struct {
-	Summary     string              `json:"summary,omitempty"`
-	Description string              `json:"description,omitempty"`
-	Tags        []string            `json:"tags,omitempty"`
-	Parameters  []Parameter         `json:"parameters,omitempty"`
-	RequestBody *RequestBody        `json:"requestBody,omitempty"`
-	Responses   map[string]Response `json:"responses"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to required scopes
+type SecurityRequirement map[string][]string
+
+// SecurityScheme describes an authentication scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
 }
 
 // Parameter describes a single operation parameter
@@ -77,7 +92,8 @@ type Schema struct {
 
 // Components holds reusable schema objects
 type Components struct {
-	Schemas map[string]Schema `json:"schemas,omitempty"`
+	Schemas         map[string]Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
 }
 
 // GenerateDocs generates OpenAPI documentation, combining group and route docs.
@@ -179,6 +195,31 @@ func (a *App) GenerateDocs() *OpenAPISpec {
 			operation.Responses["200"] = Response{Description: "Success"}
 		}
 
+		// Every auth layer must pass, so all schemes go into one requirement
+		// object (AND); separate objects would mean any one of them (OR).
+		if len(route.security) > 0 {
+			if spec.Components.SecuritySchemes == nil {
+				spec.Components.SecuritySchemes = make(map[string]SecurityScheme)
+			}
+			req := make(SecurityRequirement, len(route.security))
+			for _, sec := range route.security {
+				spec.Components.SecuritySchemes[sec.name] = sec.scheme
+				scopes := req[sec.name]
+				if scopes == nil {
+					scopes = []string{}
+				}
+				for _, scope := range sec.scopes {
+					if !slices.Contains(scopes, scope) {
+						scopes = append(scopes, scope)
+					}
+				}
+				req[sec.name] = scopes
+			}
+			operation.Security = []SecurityRequirement{req}
+			operation.Responses["401"] = Response{Description: "Unauthorized"}
+			operation.Responses["403"] = Response{Description: "Forbidden"}
+		}
+
 		methodStr := strings.ToLower(string(route.Method))
 		spec.Paths[route.Path][methodStr] = operation
 	}
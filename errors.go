@@ -8,4 +8,19 @@ var (
 	ErrForbidden       = errors.New("forbidden")
 	ErrNotFound        = errors.New("not found")
 	ErrInvalidRedirect = errors.New("invalid redirect code")
+	ErrRequestTooLarge = errors.New("request entity too large")
+	ErrFileTooLarge    = errors.New("file too large")
+	ErrUnsafePath      = errors.New("unsafe file path")
+	ErrInvalidCookie   = errors.New("invalid cookie")
+	ErrInvalidKey      = errors.New("invalid or missing key")
+	ErrNoSession       = errors.New("session middleware not installed")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrTimeout         = errors.New("handler timeout")
+	ErrClientClosed    = errors.New("client closed request")
+	ErrUpgradeRequired = errors.New("upgrade required")
+
+	ErrPreconditionFailed  = errors.New("precondition failed")
+	ErrRangeNotSatisfiable = errors.New("requested range not satisfiable")
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	ErrTooManyRequests     = errors.New("too many requests")
 )
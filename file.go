@@ -0,0 +1,415 @@
+package bolt
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRanges limits the number of ranges served in one multipart/byteranges response
+const maxRanges = 32
+
+// File sends a file from disk with Range and conditional request support.
+// Missing files return ErrNotFound and unreadable ones ErrForbidden.
+func (c *Context) File(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fileOpenError(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fileOpenError(err)
+	}
+	if info.IsDir() {
+		return ErrNotFound
+	}
+	c.setDefaultETag(info)
+	return c.serveContent(filepath.Base(name), info.ModTime(), info.Size(), f)
+}
+
+// FileFS sends a file from fsys, e.g. an embed.FS, with Range and conditional
+// request support. Files that cannot seek are streamed without ranges.
+func (c *Context) FileFS(fsys fs.FS, name string) error {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || !fs.ValidPath(name) {
+		return ErrNotFound
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fileOpenError(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fileOpenError(err)
+	}
+	if info.IsDir() {
+		return ErrNotFound
+	}
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return c.Stream(ContentType(contentTypeByName(name)), f)
+	}
+	c.setDefaultETag(info)
+	return c.serveContent(path.Base(name), info.ModTime(), info.Size(), rs)
+}
+
+// Attachment sends r as a download named name. Readers that can seek get
+// Range support; others are streamed.
+func (c *Context) Attachment(name string, r io.Reader) error {
+	c.headers.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	if rs, ok := r.(io.ReadSeeker); ok {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return c.serveContent(name, time.Time{}, size, rs)
+	}
+	return c.Stream(ContentType(contentTypeByName(name)), r)
+}
+
+// Stream copies r to the response with the given content type. The copy goes
+// through io.ReaderFrom, so *os.File sources can use sendfile.
+func (c *Context) Stream(contentType ContentType, r io.Reader) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.headers.Set("Content-Type", string(contentType))
+	status := http.StatusOK
+	if c.StatusCode != 0 {
+		status = int(c.StatusCode)
+	}
+	c.StatusCode = StatusCode(status)
+	c.Response.WriteHeader(status)
+	_, err := io.Copy(c.Response, r)
+	return err
+}
+
+// fileOpenError maps file system errors to bolt errors.
+func fileOpenError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrForbidden
+	}
+	return err
+}
+
+// contentTypeByName returns the MIME type for a file extension.
+func contentTypeByName(name string) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// setDefaultETag sets an ETag from size and mtime unless one is already set.
+// It is strong so If-Range can match it; Compress weakens it when it
+// re-encodes the body.
+func (c *Context) setDefaultETag(info fs.FileInfo) {
+	if c.headers.Get("ETag") != "" || info.ModTime().IsZero() {
+		return
+	}
+	c.headers.Set("ETag", `"`+strconv.FormatInt(info.Size(), 36)+"-"+strconv.FormatInt(info.ModTime().UnixNano(), 36)+`"`)
+}
+
+// httpRange is a byte range within the content
+type httpRange struct {
+	start, length int64
+}
+
+// contentRange formats the Content-Range value for the range.
+func (r httpRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(r.start, 10) + "-" + strconv.FormatInt(r.start+r.length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// serveContent writes content honoring conditional and Range headers.
+// Precondition and range failures are returned as errors for the ErrorHandler.
+func (c *Context) serveContent(name string, modTime time.Time, size int64, content io.ReadSeeker) error {
+	r := c.Request
+	if !modTime.IsZero() && !modTime.Equal(time.Unix(0, 0)) {
+		c.headers.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	etag := c.headers.Get("ETag")
+
+	// Preconditions in the order of RFC 9110 section 13.2.2.
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !etagListMatches(im, etag, true) {
+			return ErrPreconditionFailed
+		}
+	} else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && modTime.Truncate(time.Second).After(t) {
+			return ErrPreconditionFailed
+		}
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagListMatches(inm, etag, false) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				return c.notModified()
+			}
+			return ErrPreconditionFailed
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modTime.IsZero() &&
+		(r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		if t, err := http.ParseTime(ims); err == nil && !modTime.Truncate(time.Second).After(t) {
+			return c.notModified()
+		}
+	}
+
+	if c.headers.Get("Content-Type") == "" {
+		ct := mime.TypeByExtension(path.Ext(name))
+		if ct == "" {
+			var sniff [512]byte
+			n, _ := io.ReadFull(content, sniff[:])
+			ct = http.DetectContentType(sniff[:n])
+			if _, err := content.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		c.headers.Set("Content-Type", ct)
+	}
+	c.headers.Set("Accept-Ranges", "bytes")
+
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" && !c.ifRangeMatches(etag, modTime) {
+		rangeHeader = ""
+	}
+
+	var ranges []httpRange
+	if rangeHeader != "" {
+		var err error
+		ranges, err = parseRange(rangeHeader, size)
+		if err == ErrRangeNotSatisfiable {
+			c.headers.Set("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+			return err
+		}
+		if err != nil || rangesTooLarge(ranges, size) {
+			ranges = nil // Serve the full content instead
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		c.headers.Set("Content-Length", strconv.FormatInt(size, 10))
+		return c.writeContent(http.StatusOK, content, 0, size)
+	case 1:
+		c.headers.Set("Content-Range", ranges[0].contentRange(size))
+		c.headers.Set("Content-Length", strconv.FormatInt(ranges[0].length, 10))
+		return c.writeContent(http.StatusPartialContent, content, ranges[0].start, ranges[0].length)
+	default:
+		return c.writeMultiRange(content, ranges, size)
+	}
+}
+
+// writeContent sends length bytes of content starting at offset.
+func (c *Context) writeContent(status int, content io.ReadSeeker, offset, length int64) error {
+	c.StatusCode = StatusCode(status)
+	c.Response.WriteHeader(status)
+	if c.Request.Method == http.MethodHead || length == 0 {
+		return nil
+	}
+	if _, err := content.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	// io.LimitedReader over *os.File still qualifies for sendfile.
+	_, err := io.Copy(c.Response, io.LimitReader(content, length))
+	return err
+}
+
+// writeMultiRange sends a multipart/byteranges response.
+func (c *Context) writeMultiRange(content io.ReadSeeker, ranges []httpRange, size int64) error {
+	contentType := c.headers.Get("Content-Type")
+
+	// Dry run to compute Content-Length with the same boundary.
+	counter := &countingWriter{}
+	mw := multipart.NewWriter(counter)
+	for _, ra := range ranges {
+		_, _ = mw.CreatePart(rangePartHeader(contentType, ra.contentRange(size)))
+		counter.n += ra.length
+	}
+	_ = mw.Close()
+
+	c.headers.Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	c.headers.Set("Content-Length", strconv.FormatInt(counter.n, 10))
+	c.StatusCode = http.StatusPartialContent
+	c.Response.WriteHeader(http.StatusPartialContent)
+	if c.Request.Method == http.MethodHead {
+		return nil
+	}
+
+	out := multipart.NewWriter(c.Response)
+	if err := out.SetBoundary(mw.Boundary()); err != nil {
+		return err
+	}
+	for _, ra := range ranges {
+		part, err := out.CreatePart(rangePartHeader(contentType, ra.contentRange(size)))
+		if err != nil {
+			return err
+		}
+		if _, err := content.Seek(ra.start, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(part, content, ra.length); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// rangePartHeader builds the header of one multipart/byteranges part.
+func rangePartHeader(contentType, contentRange string) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader, 2)
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Range", contentRange)
+	return h
+}
+
+// countingWriter counts bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// notModified sends a 304 without entity headers.
+func (c *Context) notModified() error {
+	c.headers.Del("Content-Type")
+	c.headers.Del("Content-Length")
+	c.headers.Del("Content-Encoding")
+	c.StatusCode = http.StatusNotModified
+	c.Response.WriteHeader(http.StatusNotModified)
+	return nil
+}
+
+// ifRangeMatches reports whether a Range header should be honored given If-Range.
+func (c *Context) ifRangeMatches(etag string, modTime time.Time) bool {
+	ir := c.Request.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, "W/") {
+		// If-Range requires a strong comparison.
+		return etag != "" && !strings.HasPrefix(etag, "W/") && ir == etag
+	}
+	if modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ir)
+	return err == nil && modTime.Truncate(time.Second).Equal(t)
+}
+
+// etagListMatches checks an If-Match or If-None-Match list against etag.
+func etagListMatches(list, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange parses a bytes Range header. It returns ErrRangeNotSatisfiable
+// when no range overlaps the content and ErrBadRequest when malformed.
+func parseRange(header string, size int64) ([]httpRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, ErrBadRequest
+	}
+	var ranges []httpRange
+	noOverlap := false
+	for _, ra := range strings.Split(spec, ",") {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+		startStr, endStr, ok := strings.Cut(ra, "-")
+		if !ok {
+			return nil, ErrBadRequest
+		}
+		startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+
+		var r httpRange
+		if startStr == "" {
+			// Suffix range: the last n bytes.
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n < 0 {
+				return nil, ErrBadRequest
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			n = min(n, size)
+			r = httpRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, ErrBadRequest
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+			end := size - 1
+			if endStr != "" {
+				e, err := strconv.ParseInt(endStr, 10, 64)
+				if err != nil || e < start {
+					return nil, ErrBadRequest
+				}
+				end = min(e, size-1)
+			}
+			r = httpRange{start: start, length: end - start + 1}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 && noOverlap {
+		return nil, ErrRangeNotSatisfiable
+	}
+	if len(ranges) > maxRanges {
+		return nil, ErrBadRequest
+	}
+	return ranges, nil
+}
+
+// rangesTooLarge reports whether the ranges together exceed the content,
+// which indicates overlapping ranges that are cheaper to serve in full.
+func rangesTooLarge(ranges []httpRange, size int64) bool {
+	var total int64
+	for _, r := range ranges {
+		total += r.length
+	}
+	return total > size
+}
@@ -0,0 +1,560 @@
+package bolt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TempFilePolicy controls whether multipart uploads may spill to disk.
+type TempFilePolicy uint8
+
+const (
+	// TempFilesAllow lets file parts larger than MaxMemory spill to temporary
+	// files in os.TempDir, which net/http does not let callers change; set
+	// TMPDIR to move them. The files are removed once the request completes.
+	TempFilesAllow TempFilePolicy = iota
+	// TempFilesDeny keeps every upload in memory and rejects requests whose
+	// body does not fit within MaxMemory.
+	TempFilesDeny
+)
+
+// MultipartConfig configures form and multipart parsing limits.
+type MultipartConfig struct {
+	MaxMemory      int64 // Bytes of a multipart form kept in memory, also the limit for one non-file field
+	MaxFileSize    int64 // Per-file limit, 0 disables the check
+	MaxRequestSize int64 // Total body limit for form requests, 0 disables the check
+	TempFiles      TempFilePolicy
+}
+
+const (
+	mimeMultipartForm = "multipart/form-data"
+	mimeURLEncoded    = "application/x-www-form-urlencoded"
+)
+
+// requestMediaType returns the media type of the request without parameters.
+func (c *Context) requestMediaType() string {
+	ct := c.Request.Header.Get("Content-Type")
+	if ct == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// limitFormBody caps the request body according to the multipart config.
+func (c *Context) limitFormBody() {
+	cfg := c.app.config.Multipart
+	limit := cfg.MaxRequestSize
+	if cfg.TempFiles == TempFilesDeny && (limit == 0 || cfg.MaxMemory < limit) {
+		limit = cfg.MaxMemory
+	}
+	if limit > 0 && c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, limit)
+	}
+}
+
+// parseForm parses url-encoded and multipart bodies once per request.
+func (c *Context) parseForm() error {
+	if c.Request.Form != nil {
+		return nil
+	}
+	if c.requestMediaType() == mimeMultipartForm {
+		_, err := c.MultipartForm()
+		return err
+	}
+	c.limitFormBody()
+	if err := c.Request.ParseForm(); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// bodyError maps body limit errors to ErrRequestTooLarge or ErrFileTooLarge
+// and other parse errors to ErrBadRequest.
+func bodyError(err error) error {
+	if errors.Is(err, ErrFileTooLarge) {
+		return ErrFileTooLarge
+	}
+	if errors.Is(err, ErrRequestTooLarge) {
+		return ErrRequestTooLarge
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return ErrRequestTooLarge
+	}
+	if errors.Is(err, multipart.ErrMessageTooLarge) {
+		return ErrRequestTooLarge
+	}
+	return ErrBadRequest
+}
+
+// FormValue returns the first value for the named form field.
+// Body values take precedence over query values.
+func (c *Context) FormValue(key string) string {
+	if err := c.parseForm(); err != nil {
+		return ""
+	}
+	return c.Request.Form.Get(key)
+}
+
+// removeMultipartForm deletes the temporary files of a form parsed on
+// c.Request unless orig shares it. net/http only cleans up after the request
+// it passed in, not after copies made by SetContext or WithValue.
+func (c *Context) removeMultipartForm(orig *Request) {
+	r := c.Request
+	if r == nil || r == orig || r.MultipartForm == nil {
+		return
+	}
+	if orig != nil && orig.MultipartForm == r.MultipartForm {
+		return
+	}
+	_ = r.MultipartForm.RemoveAll()
+}
+
+// MultipartForm parses a multipart/form-data body and returns the form.
+// The configured size limits are enforced while parsing, so an oversized
+// file is rejected before it is buffered or spilled to disk.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.Request.MultipartForm != nil {
+		return c.Request.MultipartForm, nil
+	}
+	c.limitFormBody()
+	cfg := c.app.config.Multipart
+	if (cfg.MaxFileSize > 0 || cfg.MaxMemory > 0) && c.Request.Body != nil {
+		_, params, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+		if boundary := params["boundary"]; boundary != "" {
+			c.Request.Body = newPartLimitReader(c.Request.Body, boundary, cfg.MaxFileSize, cfg.MaxMemory)
+		}
+	}
+	if err := c.Request.ParseMultipartForm(c.app.config.Multipart.MaxMemory); err != nil {
+		return nil, bodyError(err)
+	}
+	if max := c.app.config.Multipart.MaxFileSize; max > 0 {
+		for _, files := range c.Request.MultipartForm.File {
+			for _, fh := range files {
+				if fh.Size > max {
+					return nil, ErrFileTooLarge
+				}
+			}
+		}
+	}
+	return c.Request.MultipartForm, nil
+}
+
+// partHeaderLimit caps the header block of a single part
+const partHeaderLimit = 16 << 10
+
+// partLimitReader enforces per-part limits while a multipart body streams
+// into ParseMultipartForm: file parts fail with ErrFileTooLarge past maxFile
+// and other fields with ErrRequestTooLarge past maxField. Part headers are
+// parsed to tell the two apart. The delimiter is matched with a KMP
+// automaton so it is found across read boundaries.
+type partLimitReader struct {
+	io.ReadCloser
+	delim    []byte // CRLF "--" boundary
+	fail     []int
+	match    int
+	maxFile  int64
+	maxField int64
+
+	header   []byte // Header block of the current part while it is read
+	inHeader bool
+	done     bool  // Past the closing delimiter
+	n        int64 // Bytes of the current part, including a partial delimiter
+	max      int64 // Limit of the current part, 0 for none
+	err      error
+}
+
+func newPartLimitReader(body io.ReadCloser, boundary string, maxFile, maxField int64) *partLimitReader {
+	delim := []byte("\r\n--" + boundary)
+	fail := make([]int, len(delim))
+	for i, k := 1, 0; i < len(delim); i++ {
+		for k > 0 && delim[i] != delim[k] {
+			k = fail[k-1]
+		}
+		if delim[i] == delim[k] {
+			k++
+		}
+		fail[i] = k
+	}
+	// The first delimiter may start the body, so begin as if after a CRLF.
+	// The preamble is discarded by the parser and limited like a field.
+	return &partLimitReader{
+		ReadCloser: body,
+		delim:      delim,
+		fail:       fail,
+		match:      2,
+		maxFile:    maxFile,
+		maxField:   maxField,
+		max:        maxField,
+		err:        ErrRequestTooLarge,
+	}
+}
+
+// Read checks the current part against its limit as the body streams through.
+func (r *partLimitReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	for _, ch := range b[:n] {
+		if r.done {
+			break
+		}
+		if r.inHeader {
+			if err := r.readHeader(ch); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		for r.match > 0 && ch != r.delim[r.match] {
+			r.match = r.fail[r.match-1]
+		}
+		if ch == r.delim[r.match] {
+			r.match++
+		}
+		r.n++
+		if r.match == len(r.delim) {
+			r.match, r.n = 0, 0
+			r.inHeader, r.header = true, r.header[:0]
+		} else if r.max > 0 && r.n-int64(r.match) > r.max {
+			return 0, r.err
+		}
+	}
+	return n, err
+}
+
+// readHeader collects the header block after a delimiter and picks the
+// limit of the part once the block is complete.
+func (r *partLimitReader) readHeader(ch byte) error {
+	if len(r.header) == partHeaderLimit {
+		return ErrRequestTooLarge
+	}
+	r.header = append(r.header, ch)
+	if string(r.header) == "--" {
+		r.done = true
+		return nil
+	}
+	if !bytes.HasSuffix(r.header, []byte("\r\n\r\n")) {
+		return nil
+	}
+	r.inHeader = false
+	r.max, r.err = r.maxField, ErrRequestTooLarge
+	_, line, _ := bytes.Cut(r.header, []byte("\r\n"))
+	h, _ := textproto.NewReader(bufio.NewReader(bytes.NewReader(line))).ReadMIMEHeader()
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		r.max, r.err = r.maxFile, ErrFileTooLarge
+	}
+	return nil
+}
+
+// FormFile returns the first file uploaded under the given field name.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files[0], nil
+}
+
+// SaveFile copies an uploaded file into dir using the sanitized client filename
+// and returns the path written. Names that would escape dir are rejected.
+func (c *Context) SaveFile(fh *multipart.FileHeader, dir string) (string, error) {
+	name := filepath.Base(filepath.Clean("/" + strings.ReplaceAll(fh.Filename, `\`, "/")))
+	if name == "/" || name == "." || !filepath.IsLocal(name) {
+		return "", ErrUnsafePath
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	src, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := root.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// --- Streaming multipart ---
+
+// MultipartReader streams the parts of a multipart body without buffering
+// them in memory or on disk.
+type MultipartReader struct {
+	r           *multipart.Reader
+	maxFileSize int64
+	err         error
+}
+
+// Part is a single streamed multipart part. Reads past the configured
+// per-file limit fail with ErrFileTooLarge.
+type Part struct {
+	*multipart.Part
+	remaining int64
+	limited   bool
+}
+
+// Read reads from the part body, enforcing the per-file limit.
+func (p *Part) Read(b []byte) (int, error) {
+	if !p.limited {
+		return p.Part.Read(b)
+	}
+	if p.remaining <= 0 {
+		// Probe for one more byte to tell an exact fit from an overflow.
+		var one [1]byte
+		n, err := p.Part.Read(one[:])
+		if n > 0 {
+			return 0, ErrFileTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(b)) > p.remaining {
+		b = b[:p.remaining]
+	}
+	n, err := p.Part.Read(b)
+	p.remaining -= int64(n)
+	return n, err
+}
+
+// MultipartReader returns a streaming reader for a multipart/form-data body.
+// Use it instead of MultipartForm for large uploads.
+func (c *Context) MultipartReader() (*MultipartReader, error) {
+	if c.requestMediaType() != mimeMultipartForm {
+		return nil, http.ErrNotMultipart
+	}
+	cfg := c.app.config.Multipart
+	if cfg.MaxRequestSize > 0 && c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, cfg.MaxRequestSize)
+	}
+	r, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return &MultipartReader{r: r, maxFileSize: cfg.MaxFileSize}, nil
+}
+
+// NextPart returns the next part or io.EOF when the body is exhausted.
+func (mr *MultipartReader) NextPart() (*Part, error) {
+	p, err := mr.r.NextPart()
+	if err != nil {
+		if err != io.EOF {
+			err = bodyError(err)
+		}
+		return nil, err
+	}
+	return &Part{Part: p, remaining: mr.maxFileSize, limited: mr.maxFileSize > 0}, nil
+}
+
+// Parts iterates over the remaining parts. Iteration stops after the first
+// error, which is also available from Err.
+func (mr *MultipartReader) Parts() iter.Seq2[*Part, error] {
+	return func(yield func(*Part, error) bool) {
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				mr.err = err
+				yield(nil, err)
+				return
+			}
+			if !yield(p, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error that stopped Parts, if any.
+func (mr *MultipartReader) Err() error {
+	return mr.err
+}
+
+// --- Form binding ---
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// formFieldInfo caches how a struct field maps to a form key
+type formFieldInfo struct {
+	index []int
+	name  string
+}
+
+// Global cache for form binding metadata to avoid repeated reflection
+var (
+	formFieldCache = make(map[reflect.Type][]formFieldInfo)
+	formFieldMutex sync.RWMutex
+)
+
+// formFields returns the bindable fields of a struct type.
+// The `form` tag is preferred, followed by the `json` tag and the field name.
+func formFields(t reflect.Type) []formFieldInfo {
+	formFieldMutex.RLock()
+	fields, ok := formFieldCache[t]
+	formFieldMutex.RUnlock()
+	if ok {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("form")
+		if tag == "" {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, formFieldInfo{index: f.Index, name: name})
+	}
+
+	formFieldMutex.Lock()
+	formFieldCache[t] = fields
+	formFieldMutex.Unlock()
+	return fields
+}
+
+// BindForm binds url-encoded or multipart form fields into a struct.
+// File fields may be *multipart.FileHeader or []*multipart.FileHeader.
+func (c *Context) BindForm(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return ErrBadRequest
+	}
+	if err := c.parseForm(); err != nil {
+		return err
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if c.Request.MultipartForm != nil {
+		files = c.Request.MultipartForm.File
+	}
+
+	rv = rv.Elem()
+	for _, info := range formFields(rv.Type()) {
+		field := rv.FieldByIndex(info.index)
+		switch {
+		case field.Type() == fileHeaderType:
+			if fhs := files[info.name]; len(fhs) > 0 {
+				field.Set(reflect.ValueOf(fhs[0]))
+			}
+		case field.Kind() == reflect.Slice && field.Type().Elem() == fileHeaderType:
+			if fhs := files[info.name]; len(fhs) > 0 {
+				field.Set(reflect.ValueOf(fhs))
+			}
+		case field.Kind() == reflect.Slice:
+			values := c.Request.Form[info.name]
+			if len(values) == 0 {
+				continue
+			}
+			slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+			for i, s := range values {
+				if err := setFormValue(slice.Index(i), s); err != nil {
+					return err
+				}
+			}
+			field.Set(slice)
+		default:
+			values, ok := c.Request.Form[info.name]
+			if !ok || len(values) == 0 {
+				continue
+			}
+			if err := setFormValue(field, values[0]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setFormValue parses a string into a scalar field.
+func setFormValue(field reflect.Value, s string) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return ErrBadRequest
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return ErrBadRequest
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return ErrBadRequest
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return ErrBadRequest
+		}
+		field.SetFloat(f)
+	default:
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// Bind decodes the request body based on its Content-Type.
+// Form and multipart bodies use BindForm, everything else BindJSON.
+func (c *Context) Bind(v interface{}) error {
+	switch c.requestMediaType() {
+	case mimeMultipartForm, mimeURLEncoded:
+		return c.BindForm(v)
+	default:
+		return c.BindJSON(v)
+	}
+}
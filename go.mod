@@ -1,13 +1,17 @@
 module bolt
 
-go 1.25
+go 1.25.0
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/goccy/go-json v0.10.2
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/crypto v0.54.0
 )
 
 require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
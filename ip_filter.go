@@ -0,0 +1,310 @@
+package bolt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"math/bits"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IPList is a set of IPv4 and IPv6 prefixes held in a path-compressed
+// binary trie, so lookups take at most one step per prefix bit. The trie is
+// replaced atomically on Reload and Replace; lookups never block.
+type IPList struct {
+	trie    atomic.Pointer[ipTrie]
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+}
+
+// NewIPList creates a list from CIDRs and single addresses.
+func NewIPList(entries ...string) (*IPList, error) {
+	l := &IPList{}
+	if err := l.Replace(entries...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// MustIPList is like NewIPList but panics on invalid entries.
+func MustIPList(entries ...string) *IPList {
+	l, err := NewIPList(entries...)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// LoadIPList reads a list file with one CIDR or address per line. Blank
+// lines and text after # are ignored. Use Watch to pick up changes.
+func LoadIPList(path string) (*IPList, error) {
+	l := &IPList{path: path}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Replace swaps in a new set of entries. On error the list is unchanged.
+func (l *IPList) Replace(entries ...string) error {
+	t := &ipTrie{}
+	for _, e := range entries {
+		p, err := parsePrefix(strings.TrimSpace(e))
+		if err != nil {
+			return err
+		}
+		t.insert(p)
+	}
+	l.trie.Store(t)
+	return nil
+}
+
+// Reload re-reads the list file. On error the previous entries stay active.
+func (l *IPList) Reload() error {
+	if l.path == "" {
+		return errors.New("bolt: IP list was not loaded from a file")
+	}
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	t := &ipTrie{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		p, err := parsePrefix(text)
+		if err != nil {
+			return errors.New("bolt: " + l.path + ":" + strconv.Itoa(line) + ": " + strings.TrimPrefix(err.Error(), "bolt: "))
+		}
+		t.insert(p)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	l.trie.Store(t)
+	l.mu.Lock()
+	l.modTime = info.ModTime()
+	l.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the list file whenever its modification time changes,
+// checking every interval. Reload errors keep the previous entries. Call the
+// returned function to stop watching.
+func (l *IPList) Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(l.path)
+				if err != nil {
+					continue
+				}
+				l.mu.Lock()
+				changed := !info.ModTime().Equal(l.modTime)
+				l.mu.Unlock()
+				if changed {
+					_ = l.Reload()
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Contains reports whether addr is inside one of the list's prefixes.
+func (l *IPList) Contains(addr netip.Addr) bool {
+	t := l.trie.Load()
+	return t != nil && t.contains(addr.Unmap().WithZone(""))
+}
+
+// Len returns the number of entries.
+func (l *IPList) Len() int {
+	if t := l.trie.Load(); t != nil {
+		return t.n
+	}
+	return 0
+}
+
+// --- Trie ---
+
+// ipTrie holds one trie per address family
+type ipTrie struct {
+	v4, v6 *trieNode
+	n      int
+}
+
+// trieNode covers prefix; terminal marks a list entry. Children continue
+// with the bit after the prefix.
+type trieNode struct {
+	prefix   netip.Prefix
+	terminal bool
+	child    [2]*trieNode
+}
+
+func (t *ipTrie) insert(p netip.Prefix) {
+	t.n++
+	link := &t.v6
+	if p.Addr().Is4() {
+		link = &t.v4
+	}
+	for {
+		cur := *link
+		if cur == nil {
+			*link = &trieNode{prefix: p, terminal: true}
+			return
+		}
+		common := min(commonPrefixLen(cur.prefix.Addr(), p.Addr()), cur.prefix.Bits(), p.Bits())
+		if common == cur.prefix.Bits() {
+			if common == p.Bits() {
+				cur.terminal = true
+				return
+			}
+			link = &cur.child[addrBit(p.Addr(), common)]
+			continue
+		}
+
+		// The prefixes diverge above cur; insert a node where they split.
+		split := &trieNode{prefix: netip.PrefixFrom(p.Addr(), common).Masked()}
+		split.child[addrBit(cur.prefix.Addr(), common)] = cur
+		if common == p.Bits() {
+			split.terminal = true
+		} else {
+			split.child[addrBit(p.Addr(), common)] = &trieNode{prefix: p, terminal: true}
+		}
+		*link = split
+		return
+	}
+}
+
+func (t *ipTrie) contains(addr netip.Addr) bool {
+	n := t.v6
+	if addr.Is4() {
+		n = t.v4
+	}
+	for n != nil {
+		if !n.prefix.Contains(addr) {
+			return false
+		}
+		if n.terminal {
+			return true
+		}
+		if n.prefix.Bits() == addr.BitLen() {
+			return false
+		}
+		n = n.child[addrBit(addr, n.prefix.Bits())]
+	}
+	return false
+}
+
+// addrBit returns bit i of addr, counting from the most significant.
+func addrBit(addr netip.Addr, i int) int {
+	if addr.Is4() {
+		b := addr.As4()
+		return int(b[i/8]>>(7-i%8)) & 1
+	}
+	b := addr.As16()
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// commonPrefixLen returns the number of leading bits a and b share.
+func commonPrefixLen(a, b netip.Addr) int {
+	var x, y []byte
+	if a.Is4() {
+		a4, b4 := a.As4(), b.As4()
+		x, y = a4[:], b4[:]
+	} else {
+		a16, b16 := a.As16(), b.As16()
+		x, y = a16[:], b16[:]
+	}
+	for i := range x {
+		if d := x[i] ^ y[i]; d != 0 {
+			return i*8 + bits.LeadingZeros8(d)
+		}
+	}
+	return len(x) * 8
+}
+
+// --- Middleware ---
+
+// IPFilterConfig configures the IPFilter middleware. Addresses come from
+// Context.RealIP, so set Config.TrustedProxies behind a load balancer.
+type IPFilterConfig struct {
+	Allow *IPList // If set, only these addresses pass
+	Deny  *IPList // Always rejected, even when allowed
+
+	// Audit is called for every rejected request. It defaults to a warning
+	// on the app logger with the address, reason, method and path.
+	Audit func(c *Context, ip, reason string)
+}
+
+// IPFilter returns middleware that rejects requests by client address with
+// ErrForbidden. Deny entries win over Allow entries.
+func IPFilter(config IPFilterConfig) Middleware {
+	audit := config.Audit
+	if audit == nil {
+		audit = func(c *Context, ip, reason string) {
+			c.Logger().WarnContext(c.Context(), "bolt: ip filter rejected request",
+				"ip", ip, "reason", reason, "method", c.Request.Method, "path", c.Request.URL.Path)
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			ip := c.RealIP()
+			addr, err := netip.ParseAddr(ip)
+			var reason string
+			switch {
+			case err != nil:
+				reason = "invalid address"
+			case config.Deny != nil && config.Deny.Contains(addr):
+				reason = "denied"
+			case config.Allow != nil && !config.Allow.Contains(addr):
+				reason = "not allowed"
+			default:
+				return next(c)
+			}
+			audit(c, ip, reason)
+			return ErrForbidden
+		}
+	}
+}
+
+// IPFilter restricts the current route, or every route in the current
+// group, by client address.
+func (cl *ChainLink) IPFilter(config IPFilterConfig) *ChainLink {
+	mw := IPFilter(config)
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, mw)
+	case *staticMount:
+		cl.app.rewrapMount(v, mw)
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if r := &cl.app.routes[i]; v.contains(r.Group) {
+				cl.app.rewrapRoute(r, mw)
+			}
+		}
+	}
+	return cl
+}
@@ -0,0 +1,138 @@
+package bolt
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIPListContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string // Inserted in order
+		in      []string
+		out     []string
+	}{
+		{"single address", []string{"192.0.2.1"},
+			[]string{"192.0.2.1", "::ffff:192.0.2.1"}, []string{"192.0.2.2", "::1"}},
+		{"shorter prefix splits an existing one", []string{"10.1.2.0/24", "10.0.0.0/8"},
+			[]string{"10.1.2.3", "10.200.0.1", "10.0.0.0"}, []string{"11.0.0.0", "9.255.255.255"}},
+		{"longer prefix below an existing one", []string{"10.0.0.0/8", "10.1.2.0/24"},
+			[]string{"10.1.2.3", "10.200.0.1"}, []string{"11.0.0.0"}},
+		{"siblings split above both", []string{"10.1.0.0/16", "10.2.0.0/16"},
+			[]string{"10.1.255.255", "10.2.0.0"}, []string{"10.0.0.1", "10.3.0.0"}},
+		{"split node later made terminal", []string{"10.1.0.0/16", "10.2.0.0/16", "10.0.0.0/14"},
+			[]string{"10.0.0.1", "10.3.0.0", "10.1.0.1"}, []string{"10.4.0.0"}},
+		{"shorter prefix splits two levels", []string{"10.1.2.0/24", "10.1.3.0/24", "10.0.0.0/8"},
+			[]string{"10.1.2.9", "10.1.3.9", "10.9.9.9"}, []string{"11.1.2.9"}},
+		{"duplicate entry", []string{"192.0.2.0/24", "192.0.2.0/24"},
+			[]string{"192.0.2.77"}, []string{"192.0.3.0"}},
+		{"unmasked CIDR", []string{"192.0.2.77/24"},
+			[]string{"192.0.2.1"}, []string{"192.0.3.1"}},
+		{"whole IPv4 space", []string{"0.0.0.0/0"},
+			[]string{"1.2.3.4", "255.255.255.255"}, []string{"2001:db8::1"}},
+		{"IPv6", []string{"2001:db8::/32", "2001:db8:1::/48"},
+			[]string{"2001:db8::1", "2001:db8:1::1", "2001:db8:ffff::1"}, []string{"2001:db9::1", "10.0.0.1"}},
+		{"IPv4-mapped CIDR", []string{"::ffff:10.0.0.0/104"},
+			[]string{"10.1.2.3"}, []string{"11.0.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewIPList(tt.entries...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.in {
+				if !l.Contains(netip.MustParseAddr(s)) {
+					t.Errorf("%s not found", s)
+				}
+			}
+			for _, s := range tt.out {
+				if l.Contains(netip.MustParseAddr(s)) {
+					t.Errorf("%s found", s)
+				}
+			}
+		})
+	}
+}
+
+// TestIPListMatchesLinearScan checks the trie against a plain prefix scan
+// for random prefixes inserted in random order.
+func TestIPListMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randAddr := func() netip.Addr {
+		// Stay within 10.0.0.0/12 so prefixes overlap often.
+		return netip.AddrFrom4([4]byte{10, byte(rng.IntN(16)), byte(rng.IntN(256)), byte(rng.IntN(256))})
+	}
+	for round := 0; round < 50; round++ {
+		var entries []string
+		var prefixes []netip.Prefix
+		for i := 0; i < 20; i++ {
+			p := netip.PrefixFrom(randAddr(), 8+rng.IntN(25)).Masked()
+			entries = append(entries, p.String())
+			prefixes = append(prefixes, p)
+		}
+		l := MustIPList(entries...)
+		for i := 0; i < 500; i++ {
+			addr := randAddr()
+			want := false
+			for _, p := range prefixes {
+				want = want || p.Contains(addr)
+			}
+			if got := l.Contains(addr); got != want {
+				t.Fatalf("entries %v: Contains(%s) = %v, want %v", entries, addr, got, want)
+			}
+		}
+	}
+}
+
+func TestIPListReplace(t *testing.T) {
+	l := MustIPList("10.0.0.0/8")
+	if err := l.Replace("192.0.2.0/24", "not-an-ip"); err == nil {
+		t.Fatal("invalid entry accepted")
+	}
+	if !l.Contains(netip.MustParseAddr("10.1.1.1")) || l.Len() != 1 {
+		t.Error("failed Replace changed the list")
+	}
+	if err := l.Replace("192.0.2.0/24", "198.51.100.1"); err != nil {
+		t.Fatal(err)
+	}
+	if l.Contains(netip.MustParseAddr("10.1.1.1")) || l.Len() != 2 {
+		t.Error("Replace kept old entries")
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config IPFilterConfig
+		remote string
+		status int
+		reason string
+	}{
+		{"allowed", IPFilterConfig{Allow: MustIPList("192.0.2.0/24")}, "192.0.2.5:1000", http.StatusNoContent, ""},
+		{"not allowed", IPFilterConfig{Allow: MustIPList("192.0.2.0/24")}, "198.51.100.5:1000", http.StatusForbidden, "not allowed"},
+		{"denied", IPFilterConfig{Deny: MustIPList("198.51.100.0/24")}, "198.51.100.5:1000", http.StatusForbidden, "denied"},
+		{"deny wins over allow", IPFilterConfig{Allow: MustIPList("192.0.2.0/24"), Deny: MustIPList("192.0.2.5")}, "192.0.2.5:1000", http.StatusForbidden, "denied"},
+		{"invalid address", IPFilterConfig{Deny: MustIPList("198.51.100.0/24")}, "garbage", http.StatusForbidden, "invalid address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reason string
+			tt.config.Audit = func(_ *Context, _, r string) { reason = r }
+			app := New()
+			app.Use(IPFilter(tt.config))
+			app.Get("/", func(c *Context) error { return c.NoContent() })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.status || reason != tt.reason {
+				t.Errorf("status = %d, reason = %q; want %d, %q", w.Code, reason, tt.status, tt.reason)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package bolt
 
 import (
+	"bufio"
+	"errors"
 	"io"
+	"iter"
+	"net/http"
 	"sync"
+	"time"
 
 	json "github.com/goccy/go-json"
 )
@@ -114,4 +119,164 @@ func (p *ByteSlicePool) Release(slice *[]byte) {
 		p.largePool.Put(slice)
 	}
 	// Large slices are not pooled to prevent memory bloat
-}
\ No newline at end of file
+}
+
+// --- Streaming responses ---
+
+// streamingJSONPool backs StreamJSON encoders
+var streamingJSONPool = NewStreamingJSONPool()
+
+// streamWriterPool reuses the buffered writers placed in front of the response
+var streamWriterPool = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewWriterSize(nil, 32<<10)
+	},
+}
+
+// StreamErrorTrailer is the trailer that reports an error after the body has started
+const StreamErrorTrailer = "X-Stream-Error"
+
+// StreamOption customizes a JSON stream
+type StreamOption func(*streamConfig)
+
+// streamConfig holds the settings of a single JSON stream
+type streamConfig struct {
+	array         bool
+	flushEvery    int
+	flushInterval time.Duration
+}
+
+// StreamAsArray writes a single JSON array instead of newline-delimited JSON.
+func StreamAsArray() StreamOption {
+	return func(sc *streamConfig) { sc.array = true }
+}
+
+// StreamFlushEvery flushes to the client after every n items.
+func StreamFlushEvery(n int) StreamOption {
+	return func(sc *streamConfig) { sc.flushEvery = n }
+}
+
+// StreamFlushInterval flushes to the client when d has passed since the last flush.
+func StreamFlushInterval(d time.Duration) StreamOption {
+	return func(sc *streamConfig) { sc.flushInterval = d }
+}
+
+// StreamJSON writes the items of seq as application/x-ndjson, or as a JSON
+// array with StreamAsArray, using constant memory. It stops early when the
+// client disconnects. Go methods cannot have type parameters, so this is a
+// function rather than a Context method.
+func StreamJSON[T any](c *Context, status int, seq iter.Seq[T], opts ...StreamOption) error {
+	return StreamJSONErr(c, status, func(yield func(T, error) bool) {
+		for v := range seq {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}, opts...)
+}
+
+// StreamJSONChan streams the values received from ch until it is closed.
+func StreamJSONChan[T any](c *Context, status int, ch <-chan T, opts ...StreamOption) error {
+	done := c.Request.Context().Done()
+	return StreamJSON(c, status, func(yield func(T) bool) {
+		for {
+			select {
+			case <-done:
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			}
+		}
+	}, opts...)
+}
+
+// StreamJSONErr streams items from a sequence that may fail. Because the
+// status line has already been sent, an error ends the stream and is
+// reported in the X-Stream-Error trailer and returned to the caller.
+func StreamJSONErr[T any](c *Context, status int, seq iter.Seq2[T, error], opts ...StreamOption) error {
+	sc := streamConfig{flushEvery: 100, flushInterval: time.Second}
+	for _, opt := range opts {
+		opt(&sc)
+	}
+
+	if sc.array {
+		c.headers.Set("Content-Type", string(ContentTypeJSON))
+	} else {
+		c.headers.Set("Content-Type", "application/x-ndjson")
+	}
+	c.headers.Del("Content-Length")
+	c.headers.Set("Trailer", StreamErrorTrailer)
+	c.StatusCode = StatusCode(status)
+	c.Response.WriteHeader(status)
+
+	bw := streamWriterPool.Get().(*bufio.Writer)
+	bw.Reset(c.Response)
+	defer func() {
+		bw.Reset(nil)
+		streamWriterPool.Put(bw)
+	}()
+	enc := streamingJSONPool.AcquireEncoder(bw)
+	defer streamingJSONPool.ReleaseEncoder(enc)
+
+	rc := http.NewResponseController(c.Response)
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	ctx := c.Request.Context()
+	err := func() error {
+		if sc.array {
+			if err := bw.WriteByte('['); err != nil {
+				return err
+			}
+		}
+		count := 0
+		lastFlush := time.Now()
+		for v, err := range seq {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if sc.array && count > 0 {
+				if err := bw.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+			count++
+			if (sc.flushEvery > 0 && count%sc.flushEvery == 0) ||
+				(sc.flushInterval > 0 && time.Since(lastFlush) >= sc.flushInterval) {
+				if err := flush(); err != nil {
+					return err
+				}
+				lastFlush = time.Now()
+			}
+		}
+		if sc.array {
+			if err := bw.WriteByte(']'); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	if ferr := flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		c.headers.Set(StreamErrorTrailer, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,434 @@
+package bolt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// JWT signing algorithms accepted by KeySet
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Token validation errors. JWTAuth reports them to clients in WWW-Authenticate
+// and returns ErrUnauthorized to the ErrorHandler.
+var (
+	ErrTokenMalformed    = errors.New("token is malformed")
+	ErrTokenUnverifiable = errors.New("token key or algorithm is not accepted")
+	ErrTokenSignature    = errors.New("token signature is invalid")
+	ErrTokenExpired      = errors.New("token is expired")
+	ErrTokenNotYetValid  = errors.New("token is not valid yet")
+	ErrTokenIssuer       = errors.New("token issuer is not accepted")
+	ErrTokenAudience     = errors.New("token audience is not accepted")
+)
+
+// jwtKey is a verification key bound to one algorithm
+type jwtKey struct {
+	alg string
+	key interface{}
+}
+
+// KeySet holds the keys used to verify JWTs, indexed by key ID. Keys can be
+// added and removed at runtime for rotation; a set loaded from a JWKS file
+// can be reloaded from disk.
+type KeySet struct {
+	mu      sync.RWMutex
+	keys    map[string]jwtKey
+	path    string
+	modTime time.Time
+}
+
+// NewKeySet creates an empty key set.
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]jwtKey)}
+}
+
+// Add registers a verification key. The algorithm follows from the key type:
+// []byte for HS256 (at least 32 bytes), *rsa.PublicKey for RS256,
+// *ecdsa.PublicKey on P-256 for ES256 and ed25519.PublicKey for EdDSA.
+// Tokens without a kid header match the key added with an empty kid.
+func (ks *KeySet) Add(kid string, key interface{}) error {
+	k, err := newJWTKey(key)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	ks.keys[kid] = k
+	ks.mu.Unlock()
+	return nil
+}
+
+// Remove drops a key, e.g. once tokens signed with it have expired.
+func (ks *KeySet) Remove(kid string) {
+	ks.mu.Lock()
+	delete(ks.keys, kid)
+	ks.mu.Unlock()
+}
+
+// newJWTKey binds key to its algorithm.
+func newJWTKey(key interface{}) (jwtKey, error) {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) < 32 {
+			return jwtKey{}, errors.New("bolt: HS256 key must be at least 32 bytes")
+		}
+		return jwtKey{alg: AlgHS256, key: k}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return jwtKey{}, errors.New("bolt: RSA key must be at least 2048 bits")
+		}
+		return jwtKey{alg: AlgRS256, key: k}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return jwtKey{}, errors.New("bolt: ES256 key must use P-256")
+		}
+		return jwtKey{alg: AlgES256, key: k}, nil
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return jwtKey{}, errors.New("bolt: invalid Ed25519 key")
+		}
+		return jwtKey{alg: AlgEdDSA, key: k}, nil
+	}
+	return jwtKey{}, errors.New("bolt: unsupported JWT key type")
+}
+
+// lookup finds the key for a token's kid. A token without kid also matches
+// the only key of a single-key set.
+func (ks *KeySet) lookup(kid string) (jwtKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if k, ok := ks.keys[kid]; ok {
+		return k, true
+	}
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, true
+		}
+	}
+	return jwtKey{}, false
+}
+
+// --- JWKS ---
+
+// jwk is a JSON Web Key as found in a JWKS document
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS reads a key set from a local JWKS file. Keys marked for
+// encryption ("use": "enc") are skipped.
+func LoadJWKS(path string) (*KeySet, error) {
+	ks := NewKeySet()
+	ks.path = path
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload re-reads the JWKS file and replaces all keys at once, so rotated
+// keys take effect atomically. It fails for sets not loaded with LoadJWKS.
+func (ks *KeySet) Reload() error {
+	if ks.path == "" {
+		return errors.New("bolt: key set was not loaded from a file")
+	}
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	ks.keys = keys.keys
+	ks.modTime = info.ModTime()
+	ks.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the JWKS file whenever its modification time changes,
+// checking every interval. Reload errors keep the previous keys. Call the
+// returned function to stop watching.
+func (ks *KeySet) Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(ks.path)
+				if err != nil {
+					continue
+				}
+				ks.mu.RLock()
+				changed := !info.ModTime().Equal(ks.modTime)
+				ks.mu.RUnlock()
+				if changed {
+					_ = ks.Reload()
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// ParseJWKS builds a key set from a JWKS document.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	ks := NewKeySet()
+	for _, k := range doc.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, errors.New("bolt: jwks key " + k.Kid + ": " + err.Error())
+		}
+		if err := ks.Add(k.Kid, key); err != nil {
+			return nil, err
+		}
+		if k.Alg != "" && ks.keys[k.Kid].alg != k.Alg {
+			return nil, errors.New("bolt: jwks key " + k.Kid + ": unsupported alg " + k.Alg)
+		}
+	}
+	return ks, nil
+}
+
+// publicKey decodes the key material of a JWK.
+func (k *jwk) publicKey() (interface{}, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "oct":
+		return b64.DecodeString(k.K)
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 point")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
+
+// --- Verification ---
+
+// Claims are the verified payload of a JWT
+type Claims map[string]interface{}
+
+// Subject returns the sub claim.
+func (cl Claims) Subject() string {
+	s, _ := cl["sub"].(string)
+	return s
+}
+
+// Scopes returns the scopes from the space-separated scope claim or the scp
+// claim, which may be a string or an array.
+func (cl Claims) Scopes() []string {
+	if s, ok := cl["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	switch v := cl["scp"].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		scopes := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// HasScope reports whether the claims grant scope.
+func (cl Claims) HasScope(scope string) bool {
+	for _, s := range cl.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// audiences returns the aud claim as a list.
+func (cl Claims) audiences() []string {
+	switch v := cl["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		aud := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+// numericDate returns a NumericDate claim.
+func (cl Claims) numericDate(name string) (time.Time, bool, error) {
+	v, ok := cl[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, ErrTokenMalformed
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true, nil
+}
+
+// VerifyJWT checks a compact JWT's signature against the key set and returns
+// its claims. Time and audience checks are done by JWTAuth.
+func (ks *KeySet) VerifyJWT(token string) (Claims, error) {
+	headerPart, rest, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrTokenMalformed
+	}
+	payloadPart, sigPart, ok := strings.Cut(rest, ".")
+	if !ok || strings.Contains(sigPart, ".") {
+		return nil, ErrTokenMalformed
+	}
+
+	b64 := base64.RawURLEncoding
+	headerJSON, err := b64.DecodeString(headerPart)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var header struct {
+		Alg  string   `json:"alg"`
+		Kid  string   `json:"kid"`
+		Crit []string `json:"crit"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || len(header.Crit) > 0 {
+		return nil, ErrTokenMalformed
+	}
+	sig, err := b64.DecodeString(sigPart)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	// The key decides the algorithm; a mismatching alg header is rejected
+	// rather than trusted, which rules out algorithm confusion.
+	key, ok := ks.lookup(header.Kid)
+	if !ok || key.alg != header.Alg {
+		return nil, ErrTokenUnverifiable
+	}
+	signed := token[:len(headerPart)+1+len(payloadPart)]
+	if !verifySignature(key, signed, sig) {
+		return nil, ErrTokenSignature
+	}
+
+	payload, err := b64.DecodeString(payloadPart)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims == nil {
+		return nil, ErrTokenMalformed
+	}
+	return claims, nil
+}
+
+// verifySignature checks sig over signed with key.
+func verifySignature(key jwtKey, signed string, sig []byte) bool {
+	switch key.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.key.([]byte))
+		mac.Write([]byte(signed))
+		return hmac.Equal(mac.Sum(nil), sig)
+	case AlgRS256:
+		sum := sha256.Sum256([]byte(signed))
+		return rsa.VerifyPKCS1v15(key.key.(*rsa.PublicKey), crypto.SHA256, sum[:], sig) == nil
+	case AlgES256:
+		if len(sig) != 64 {
+			return false
+		}
+		sum := sha256.Sum256([]byte(signed))
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key.key.(*ecdsa.PublicKey), sum[:], r, s)
+	case AlgEdDSA:
+		return ed25519.Verify(key.key.(ed25519.PublicKey), []byte(signed), sig)
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package bolt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHMACKey = []byte("0123456789abcdef0123456789abcdef")
+
+// signJWT builds a compact JWT. sign receives the signing input and returns
+// the raw signature.
+func signJWT(t *testing.T, header, claims map[string]any, sign func(signed string) []byte) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding
+	signed := b64.EncodeToString(h) + "." + b64.EncodeToString(p)
+	return signed + "." + b64.EncodeToString(sign(signed))
+}
+
+func hs256(key []byte) func(string) []byte {
+	return func(signed string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKeys := NewKeySet()
+	if err := hmacKeys.Add("", testHMACKey); err != nil {
+		t.Fatal(err)
+	}
+	edKeys := NewKeySet()
+	if err := edKeys.Add("ed", pub); err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "alice"}
+	valid := signJWT(t, map[string]any{"alg": "HS256"}, claims, hs256(testHMACKey))
+	head, _, _ := strings.Cut(valid, ".")
+	_, sig, _ := strings.Cut(strings.TrimPrefix(valid, head+"."), ".")
+
+	tests := []struct {
+		name  string
+		keys  *KeySet
+		token string
+		err   error
+	}{
+		{"valid HS256", hmacKeys, valid, nil},
+		{"valid EdDSA", edKeys,
+			signJWT(t, map[string]any{"alg": "EdDSA", "kid": "ed"}, claims, func(s string) []byte { return ed25519.Sign(priv, []byte(s)) }),
+			nil},
+		{"alg none", hmacKeys,
+			signJWT(t, map[string]any{"alg": "none"}, claims, func(string) []byte { return nil }),
+			ErrTokenUnverifiable},
+		{"alg none without signature", hmacKeys,
+			strings.TrimSuffix(signJWT(t, map[string]any{"alg": "none"}, claims, func(string) []byte { return nil }), "."),
+			ErrTokenMalformed},
+		{"HS256 signed with the public key", edKeys,
+			signJWT(t, map[string]any{"alg": "HS256", "kid": "ed"}, claims, hs256(pub)),
+			ErrTokenUnverifiable},
+		{"EdDSA header on an HMAC key", hmacKeys,
+			signJWT(t, map[string]any{"alg": "EdDSA"}, claims, func(s string) []byte { return ed25519.Sign(priv, []byte(s)) }),
+			ErrTokenUnverifiable},
+		{"unknown kid", edKeys,
+			signJWT(t, map[string]any{"alg": "EdDSA", "kid": "other"}, claims, func(s string) []byte { return ed25519.Sign(priv, []byte(s)) }),
+			ErrTokenUnverifiable},
+		{"wrong HMAC key", hmacKeys,
+			signJWT(t, map[string]any{"alg": "HS256"}, claims, hs256([]byte("fedcba9876543210fedcba9876543210"))),
+			ErrTokenSignature},
+		{"tampered payload", hmacKeys,
+			head + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + sig,
+			ErrTokenSignature},
+		{"crit header", hmacKeys,
+			signJWT(t, map[string]any{"alg": "HS256", "crit": []string{"exp"}}, claims, hs256(testHMACKey)),
+			ErrTokenMalformed},
+		{"two parts", hmacKeys, "a.b", ErrTokenMalformed},
+		{"four parts", hmacKeys, valid + ".x", ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keys.VerifyJWT(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && got.Subject() != "alice" {
+				t.Errorf("sub = %q, want alice", got.Subject())
+			}
+		})
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	keys := NewKeySet()
+	if err := keys.Add("", testHMACKey); err != nil {
+		t.Fatal(err)
+	}
+	app := New()
+	app.Use(JWTAuth(JWTConfig{Keys: keys, Issuer: "https://issuer.example", Audience: []string{"api"}, ClockSkew: 30 * time.Second}))
+	app.Get("/me", func(c *Context) error { return c.String(http.StatusOK, c.Claims().Subject()) })
+
+	now := time.Now()
+	token := func(claims map[string]any) string {
+		base := map[string]any{"sub": "alice", "iss": "https://issuer.example", "aud": "api"}
+		for k, v := range claims {
+			base[k] = v
+		}
+		return signJWT(t, map[string]any{"alg": "HS256"}, base, hs256(testHMACKey))
+	}
+
+	tests := []struct {
+		name   string
+		auth   string
+		status int
+		reason string // Expected in WWW-Authenticate
+	}{
+		{"valid", "Bearer " + token(map[string]any{"exp": now.Add(time.Hour).Unix()}), http.StatusOK, ""},
+		{"expired", "Bearer " + token(map[string]any{"exp": now.Add(-time.Hour).Unix()}), http.StatusUnauthorized, ErrTokenExpired.Error()},
+		{"expired within skew", "Bearer " + token(map[string]any{"exp": now.Add(-10 * time.Second).Unix()}), http.StatusOK, ""},
+		{"not yet valid", "Bearer " + token(map[string]any{"nbf": now.Add(time.Hour).Unix()}), http.StatusUnauthorized, ErrTokenNotYetValid.Error()},
+		{"exp not a number", "Bearer " + token(map[string]any{"exp": "tomorrow"}), http.StatusUnauthorized, "invalid_token"},
+		{"wrong issuer", "Bearer " + token(map[string]any{"iss": "https://evil.example"}), http.StatusUnauthorized, ErrTokenIssuer.Error()},
+		{"wrong audience", "Bearer " + token(map[string]any{"aud": []string{"web", "admin"}}), http.StatusUnauthorized, ErrTokenAudience.Error()},
+		{"alg none", "Bearer " + signJWT(t, map[string]any{"alg": "none"}, map[string]any{"sub": "alice"}, func(string) []byte { return nil }),
+			http.StatusUnauthorized, ErrTokenUnverifiable.Error()},
+		{"missing", "", http.StatusUnauthorized, "Bearer"},
+		{"basic scheme", "Basic YWxpY2U6cHc=", http.StatusUnauthorized, "Bearer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && w.Body.String() != "alice" {
+				t.Errorf("body = %q, want the subject", w.Body.String())
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, tt.reason) {
+				t.Errorf("WWW-Authenticate = %q, want it to mention %q", challenge, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package bolt
+
+import "context"
+
+// local is a single request-scoped key/value pair
+type local struct {
+	key   string
+	value interface{}
+}
+
+// Set stores a request-scoped value, e.g. the authenticated user set by middleware.
+// Values live in a small pooled slice that is cleared when the Context is released.
+func (c *Context) Set(key string, value interface{}) {
+	for i := range c.locals {
+		if c.locals[i].key == key {
+			c.locals[i].value = value
+			return
+		}
+	}
+	c.locals = append(c.locals, local{key: key, value: value})
+}
+
+// Get returns a request-scoped value stored with Set.
+func (c *Context) Get(key string) (interface{}, bool) {
+	for i := range c.locals {
+		if c.locals[i].key == key {
+			return c.locals[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// Local returns a request-scoped value as T.
+// The second result is false if the key is missing or holds another type.
+func Local[T any](c *Context, key string) (T, bool) {
+	v, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
+
+// MustLocal returns a request-scoped value as T and panics if it is missing.
+func MustLocal[T any](c *Context, key string) T {
+	t, ok := Local[T](c, key)
+	if !ok {
+		panic("bolt: missing or mistyped local " + key)
+	}
+	return t
+}
+
+// resetLocals clears stored values so they can be collected, keeping small slices for reuse.
+func (c *Context) resetLocals() {
+	if cap(c.locals) > MaxParams {
+		c.locals = nil
+		return
+	}
+	clear(c.locals)
+	c.locals = c.locals[:0]
+}
+
+// Context returns the request's context.Context, which is cancelled when the
+// client disconnects or the request deadline passes. Pass it to DB and RPC calls.
+func (c *Context) Context() context.Context {
+	return c.Request.Context()
+}
+
+// SetContext replaces the request's context.Context. c.Request becomes a
+// shallow copy; a multipart form parsed on it is still cleaned up when the
+// request completes.
+func (c *Context) SetContext(ctx context.Context) {
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// WithValue stores a value on the request's context.Context and updates c.Request.
+// Prefer Set for values that only bolt handlers need to read.
+func (c *Context) WithValue(key, value interface{}) {
+	c.SetContext(context.WithValue(c.Request.Context(), key, value))
+}
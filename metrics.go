@@ -0,0 +1,393 @@
+package bolt
+
+import (
+	"bytes"
+	"maps"
+	"math"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsConfig configures request metrics.
+type MetricsConfig struct {
+	Path        string              // Endpoint registered by App.Metrics, defaults to "/metrics"
+	Namespace   string              // Metric name prefix, defaults to "bolt"
+	Buckets     []float64           // Latency buckets in seconds
+	SizeBuckets []float64           // Response size buckets in bytes
+	Skip        func(*Context) bool // Requests to leave out, e.g. health checks
+}
+
+// DefaultMetricsConfig returns the default metrics configuration.
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		Path:        "/metrics",
+		Namespace:   "bolt",
+		Buckets:     []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		SizeBuckets: []float64{100, 1000, 10000, 100000, 1e6, 1e7},
+	}
+}
+
+// Metrics collects per-route RED metrics (rate, errors, duration) and
+// serves them with Go runtime and pool statistics in the Prometheus text
+// and OpenMetrics formats. Pool gets are counted from App.Metrics or the
+// first scrape on, so apps without metrics skip the shared counters.
+type Metrics struct {
+	config   MetricsConfig
+	mu       sync.RWMutex
+	series   map[seriesKey]*requestSeries
+	inFlight map[routeKey]*atomic.Int64
+}
+
+// seriesKey labels a request series
+type seriesKey struct {
+	method, route, status string
+}
+
+// routeKey labels the in-flight gauge
+type routeKey struct {
+	method, route string
+}
+
+// requestSeries holds the histograms of one label set. The count of the
+// duration histogram doubles as the request counter.
+type requestSeries struct {
+	duration histogram
+	size     histogram
+}
+
+// histogram is a cumulative-on-export Prometheus histogram
+type histogram struct {
+	bounds []float64
+	counts []atomic.Uint64 // One per bound plus +Inf
+	sum    atomic.Uint64   // float64 bits
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i].Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// NewMetrics creates a metrics collector. Install its Middleware and serve
+// its Handler, or use App.Metrics to do both.
+func NewMetrics(config ...MetricsConfig) *Metrics {
+	cfg := DefaultMetricsConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		def := DefaultMetricsConfig()
+		if cfg.Path == "" {
+			cfg.Path = def.Path
+		}
+		if cfg.Namespace == "" {
+			cfg.Namespace = def.Namespace
+		}
+		if cfg.Buckets == nil {
+			cfg.Buckets = def.Buckets
+		}
+		if cfg.SizeBuckets == nil {
+			cfg.SizeBuckets = def.SizeBuckets
+		}
+	}
+	cfg.Buckets = slices.Sorted(slices.Values(cfg.Buckets))
+	cfg.SizeBuckets = slices.Sorted(slices.Values(cfg.SizeBuckets))
+	return &Metrics{
+		config:   cfg,
+		series:   make(map[seriesKey]*requestSeries),
+		inFlight: make(map[routeKey]*atomic.Int64),
+	}
+}
+
+// Metrics installs request metrics on every route added afterwards and
+// serves them at MetricsConfig.Path.
+func (a *App) Metrics(config ...MetricsConfig) *Metrics {
+	m := NewMetrics(config...)
+	a.countPoolStats()
+	a.Use(m.Middleware())
+	a.Get(m.config.Path, m.Handler())
+	return m
+}
+
+// Middleware records request count, latency, response size and in-flight
+// requests, labeled by method, route pattern and status class. Errors are
+// passed to the error handler first so the final status is counted.
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if c.route == m.config.Path || m.config.Skip != nil && m.config.Skip(c) {
+				return next(c)
+			}
+			gauge := m.gauge(routeKey{c.Request.Method, c.route})
+			gauge.Add(1)
+			start := time.Now()
+
+			err := next(c)
+			c.handleError(err)
+
+			elapsed := time.Since(start).Seconds()
+			gauge.Add(-1)
+			s := m.seriesFor(seriesKey{c.Request.Method, c.route, statusClass(c.ResponseStatus())})
+			s.duration.observe(elapsed)
+			s.size.observe(float64(c.ResponseSize()))
+			return nil
+		}
+	}
+}
+
+// statusClass returns "2xx" style labels; unwritten responses count as 200.
+func statusClass(status int) string {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return string(rune('0'+status/100)) + "xx"
+}
+
+func (m *Metrics) gauge(k routeKey) *atomic.Int64 {
+	m.mu.RLock()
+	g := m.inFlight[k]
+	m.mu.RUnlock()
+	if g != nil {
+		return g
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if g = m.inFlight[k]; g == nil {
+		g = new(atomic.Int64)
+		m.inFlight[k] = g
+	}
+	return g
+}
+
+func (m *Metrics) seriesFor(k seriesKey) *requestSeries {
+	m.mu.RLock()
+	s := m.series[k]
+	m.mu.RUnlock()
+	if s != nil {
+		return s
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s = m.series[k]; s == nil {
+		s = &requestSeries{duration: newHistogram(m.config.Buckets), size: newHistogram(m.config.SizeBuckets)}
+		m.series[k] = s
+	}
+	return s
+}
+
+// Handler serves the metrics. Scrapers asking for
+// application/openmetrics-text get OpenMetrics 1.0, others the Prometheus
+// text format 0.0.4.
+func (m *Metrics) Handler() Handler {
+	return func(c *Context) error {
+		om := strings.Contains(c.Request.Header.Get("Accept"), "application/openmetrics-text")
+		w := &metricsWriter{openMetrics: om}
+		if c.app != nil {
+			c.app.countPoolStats()
+		}
+		m.write(w, c.app)
+		contentType := ContentType("text/plain; version=0.0.4; charset=utf-8")
+		if om {
+			w.buf.WriteString("# EOF\n")
+			contentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+		}
+		return c.Bytes(http.StatusOK, contentType, w.buf.Bytes())
+	}
+}
+
+// countPoolStats starts counting gets of the app's pools.
+func (a *App) countPoolStats() {
+	a.router.paramStats.enabled.Store(true)
+	if a.contextPool != nil {
+		a.contextPool.counter.enabled.Store(true)
+	}
+	if a.bufferPool != nil {
+		a.bufferPool.counter.enabled.Store(true)
+	}
+}
+
+// write renders all metric families.
+func (m *Metrics) write(w *metricsWriter, app *App) {
+	ns := m.config.Namespace + "_"
+
+	// Snapshot the label sets; the values themselves are atomic.
+	type entry struct {
+		key    seriesKey
+		series *requestSeries
+	}
+	type gaugeEntry struct {
+		key   routeKey
+		value int64
+	}
+	m.mu.RLock()
+	entries := make([]entry, 0, len(m.series))
+	for k, s := range m.series {
+		entries = append(entries, entry{k, s})
+	}
+	gauges := make([]gaugeEntry, 0, len(m.inFlight))
+	for k, g := range m.inFlight {
+		gauges = append(gauges, gaugeEntry{k, g.Load()})
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.key.route+" "+a.key.method+" "+a.key.status, b.key.route+" "+b.key.method+" "+b.key.status)
+	})
+	slices.SortFunc(gauges, func(a, b gaugeEntry) int {
+		return strings.Compare(a.key.route+" "+a.key.method, b.key.route+" "+b.key.method)
+	})
+
+	w.family(ns+"http_requests", "counter", "Total HTTP requests.")
+	for _, e := range entries {
+		w.sample(ns+"http_requests_total", float64(e.series.duration.total()), "method", e.key.method, "route", e.key.route, "status", e.key.status)
+	}
+	w.family(ns+"http_request_duration_seconds", "histogram", "HTTP request latency.")
+	for _, e := range entries {
+		w.histogram(ns+"http_request_duration_seconds", &e.series.duration, "method", e.key.method, "route", e.key.route, "status", e.key.status)
+	}
+	w.family(ns+"http_response_size_bytes", "histogram", "HTTP response body size.")
+	for _, e := range entries {
+		w.histogram(ns+"http_response_size_bytes", &e.series.size, "method", e.key.method, "route", e.key.route, "status", e.key.status)
+	}
+	w.family(ns+"http_requests_in_flight", "gauge", "HTTP requests being served.")
+	for _, g := range gauges {
+		w.sample(ns+"http_requests_in_flight", float64(g.value), "method", g.key.method, "route", g.key.route)
+	}
+
+	if app != nil {
+		pools := map[string]PoolStats{"params": app.router.ParamPoolStats()}
+		if app.contextPool != nil {
+			pools["context"] = app.contextPool.Stats()
+		}
+		if app.bufferPool != nil {
+			pools["buffer"] = app.bufferPool.Stats()
+		}
+		names := slices.Sorted(maps.Keys(pools))
+		w.family(ns+"pool_gets", "counter", "Objects taken from bolt's pools.")
+		for _, name := range names {
+			w.sample(ns+"pool_gets_total", float64(pools[name].Gets), "pool", name)
+		}
+		w.family(ns+"pool_misses", "counter", "Pool gets that had to allocate.")
+		for _, name := range names {
+			w.sample(ns+"pool_misses_total", float64(pools[name].Misses), "pool", name)
+		}
+	}
+
+	writeRuntimeMetrics(w)
+}
+
+// total returns the number of observations.
+func (h *histogram) total() uint64 {
+	var n uint64
+	for i := range h.counts {
+		n += h.counts[i].Load()
+	}
+	return n
+}
+
+// writeRuntimeMetrics renders Go runtime statistics with the names the
+// Prometheus client library uses.
+func writeRuntimeMetrics(w *metricsWriter) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	w.family("go_info", "gauge", "Information about the Go environment.")
+	w.sample("go_info", 1, "version", runtime.Version())
+	w.family("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	w.sample("go_goroutines", float64(runtime.NumGoroutine()))
+	w.family("go_memstats_alloc_bytes", "gauge", "Number of bytes allocated and still in use.")
+	w.sample("go_memstats_alloc_bytes", float64(ms.Alloc))
+	w.family("go_memstats_sys_bytes", "gauge", "Number of bytes obtained from system.")
+	w.sample("go_memstats_sys_bytes", float64(ms.Sys))
+	w.family("go_memstats_heap_objects", "gauge", "Number of allocated objects.")
+	w.sample("go_memstats_heap_objects", float64(ms.HeapObjects))
+	w.family("go_memstats_mallocs", "counter", "Total number of mallocs.")
+	w.sample("go_memstats_mallocs_total", float64(ms.Mallocs))
+	w.family("go_gc_cycles", "counter", "Number of completed GC cycles.")
+	w.sample("go_gc_cycles_total", float64(ms.NumGC))
+	w.family("go_gc_pause_seconds", "counter", "Total GC stop-the-world pause time.")
+	w.sample("go_gc_pause_seconds_total", float64(ms.PauseTotalNs)/1e9)
+}
+
+// metricsWriter renders the Prometheus text or OpenMetrics format
+type metricsWriter struct {
+	buf         bytes.Buffer
+	openMetrics bool
+}
+
+// family writes HELP and TYPE. Counter families are named without _total
+// in OpenMetrics and with it in the Prometheus text format.
+func (w *metricsWriter) family(name, typ, help string) {
+	if typ == "counter" && !w.openMetrics {
+		name += "_total"
+	}
+	w.buf.WriteString("# HELP " + name + " " + help + "\n# TYPE " + name + " " + typ + "\n")
+}
+
+// sample writes one line; labels are name/value pairs.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(labels[i])
+			w.buf.WriteString(`="`)
+			w.buf.WriteString(escapeLabel(labels[i+1]))
+			w.buf.WriteByte('"')
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatMetric(value))
+	w.buf.WriteByte('\n')
+}
+
+// histogram writes the cumulative buckets, sum and count of h.
+func (w *metricsWriter) histogram(name string, h *histogram, labels ...string) {
+	withLE := append(slices.Clone(labels), "le", "")
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		if i < len(h.bounds) {
+			withLE[len(withLE)-1] = formatMetric(h.bounds[i])
+		} else {
+			withLE[len(withLE)-1] = "+Inf"
+		}
+		w.sample(name+"_bucket", float64(cumulative), withLE...)
+	}
+	w.sample(name+"_sum", math.Float64frombits(h.sum.Load()), labels...)
+	w.sample(name+"_count", float64(cumulative), labels...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatMetric(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,365 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// OTLPConfig configures an OTLPExporter.
+type OTLPConfig struct {
+	// Endpoint is the full traces URL, e.g. "http://localhost:4318/v1/traces".
+	// Tests can point it at an httptest.Server.
+	Endpoint string
+	Headers  map[string]string // Extra request headers, e.g. an API key
+	Client   *http.Client      // Defaults to a client with Timeout
+
+	ServiceName string      // Reported as service.name
+	Resource    []slog.Attr // Extra resource attributes, e.g. deployment.environment
+
+	BatchSize int           // Spans per request, defaults to 512
+	QueueSize int           // Spans waiting to be sent, defaults to 2048
+	Interval  time.Duration // Maximum delay before a partial batch is sent, defaults to 5s
+	Timeout   time.Duration // Per request, defaults to 10s
+
+	Logger  *slog.Logger // Receives export failures, defaults to slog.Default()
+	OnError func(error)  // Called when a batch fails, defaults to a warning on Logger
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding. Spans are queued and sent in batches from a
+// background goroutine; when the queue is full new spans are dropped rather
+// than slowing down requests.
+type OTLPExporter struct {
+	config   OTLPConfig
+	resource otlpResource
+	queue    chan SpanData
+	flush    chan chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	mu       sync.RWMutex // Held for writing while closing, so no span is queued after run drains
+	closed   bool
+	dropped  atomic.Int64
+}
+
+// NewOTLPExporter starts an exporter. Call Shutdown to send the remaining
+// spans before the process exits.
+func NewOTLPExporter(config OTLPConfig) *OTLPExporter {
+	if config.Endpoint == "" {
+		panic("bolt: OTLP exporter needs an endpoint")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 2048
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: config.Timeout}
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	if config.OnError == nil {
+		logger := config.Logger
+		config.OnError = func(err error) {
+			logger.Warn("bolt: otlp export failed", "error", err)
+		}
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "unknown_service"
+	}
+
+	e := &OTLPExporter{
+		config:  config,
+		queue:   make(chan SpanData, config.QueueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	e.resource.Attributes = otlpAttrs(append([]slog.Attr{slog.String("service.name", config.ServiceName)}, config.Resource...))
+	go e.run()
+	return e
+}
+
+// ExportSpan queues a span, dropping it if the queue is full or the
+// exporter is shut down.
+func (e *OTLPExporter) ExportSpan(span SpanData) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(1)
+		return
+	}
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of spans lost to a full queue or after Shutdown.
+func (e *OTLPExporter) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+// Flush sends all queued spans and waits until they are delivered or ctx ends.
+func (e *OTLPExporter) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case e.flush <- ack:
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown sends the queued spans and stops the exporter.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.done)
+	}
+	e.mu.Unlock()
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run batches queued spans until Shutdown.
+func (e *OTLPExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, e.config.BatchSize)
+
+	send := func() {
+		if len(batch) > 0 {
+			if err := e.send(batch); err != nil {
+				e.config.OnError(err)
+			}
+			clear(batch)
+			batch = batch[:0]
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-e.queue:
+				batch = append(batch, span)
+				if len(batch) == e.config.BatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) == e.config.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flush:
+			drain()
+			close(ack)
+		case <-e.done:
+			drain()
+			return
+		}
+	}
+}
+
+// send posts one batch.
+func (e *OTLPExporter) send(batch []SpanData) error {
+	body, err := json.Marshal(e.encode(batch))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return errors.New("bolt: otlp collector answered " + resp.Status)
+	}
+	return nil
+}
+
+// --- OTLP JSON encoding ---
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    SpanStatus `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue sets exactly one field; 64-bit integers are strings in the
+// protobuf JSON mapping.
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// encode builds the export request for a batch.
+func (e *OTLPExporter) encode(batch []SpanData) otlpRequest {
+	spans := make([]otlpSpan, len(batch))
+	for i, s := range batch {
+		sc := s.SpanContext
+		o := otlpSpan{
+			TraceID:           sc.TraceID.String(),
+			SpanID:            sc.SpanID.String(),
+			TraceState:        sc.TraceState,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        otlpAttrs(s.Attrs),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if sc.ParentSpanID.IsValid() {
+			o.ParentSpanID = sc.ParentSpanID.String()
+		}
+		for _, ev := range s.Events {
+			o.Events = append(o.Events, otlpEvent{
+				TimeUnixNano: unixNano(ev.Time),
+				Name:         ev.Name,
+				Attributes:   otlpAttrs(ev.Attrs),
+			})
+		}
+		spans[i] = o
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "bolt"}, Spans: spans}},
+	}}}
+}
+
+// otlpAttrs converts slog attributes to OTLP key/values.
+func otlpAttrs(attrs []slog.Attr) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpAnyValue
+		switch val := a.Value.Resolve(); val.Kind() {
+		case slog.KindInt64:
+			s := strconv.FormatInt(val.Int64(), 10)
+			v.IntValue = &s
+		case slog.KindUint64:
+			s := strconv.FormatUint(val.Uint64(), 10)
+			v.IntValue = &s
+		case slog.KindDuration:
+			s := strconv.FormatInt(int64(val.Duration()), 10)
+			v.IntValue = &s
+		case slog.KindFloat64:
+			f := val.Float64()
+			v.DoubleValue = &f
+		case slog.KindBool:
+			b := val.Bool()
+			v.BoolValue = &b
+		default:
+			s := val.String()
+			v.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return kvs
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,197 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is an httptest OTLP/HTTP endpoint that records every request.
+type collector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+	status   int
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	col := &collector{status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		var req otlpRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("payload is not OTLP JSON: %v", err)
+		}
+		col.mu.Lock()
+		col.requests = append(col.requests, req)
+		col.headers = append(col.headers, r.Header.Clone())
+		status := col.status
+		col.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return col, srv
+}
+
+// batchSizes returns the number of spans in each received request.
+func (col *collector) batchSizes() []int {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	sizes := make([]int, len(col.requests))
+	for i, req := range col.requests {
+		sizes[i] = len(req.ResourceSpans[0].ScopeSpans[0].Spans)
+	}
+	return sizes
+}
+
+func testSpan(name string) SpanData {
+	start := time.Unix(1700000000, 0)
+	return SpanData{
+		Name:        name,
+		Kind:        SpanKindServer,
+		SpanContext: SpanContext{TraceID: newTraceID(), SpanID: newSpanID()},
+		Start:       start,
+		End:         start.Add(1500 * time.Nanosecond),
+		Attrs:       []slog.Attr{slog.Int("http.status_code", 500), slog.String("http.route", "/users/:id")},
+		Status:      SpanStatusError,
+	}
+}
+
+func TestOTLPExporterBatching(t *testing.T) {
+	col, srv := newCollector(t)
+	e := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL, BatchSize: 2, Interval: time.Hour})
+	defer e.Shutdown(context.Background())
+
+	for i := 0; i < 5; i++ {
+		e.ExportSpan(testSpan("span"))
+	}
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sizes := col.batchSizes()
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Fatalf("batch sizes = %v, want [2 2 1]", sizes)
+	}
+}
+
+func TestOTLPExporterPayload(t *testing.T) {
+	col, srv := newCollector(t)
+	e := NewOTLPExporter(OTLPConfig{
+		Endpoint:    srv.URL,
+		Headers:     map[string]string{"X-Api-Key": "secret"},
+		ServiceName: "shop",
+		Interval:    time.Hour,
+	})
+	span := testSpan("GET /users/:id")
+	e.ExportSpan(span)
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(col.requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(col.requests))
+	}
+	if h := col.headers[0]; h.Get("Content-Type") != "application/json" || h.Get("X-Api-Key") != "secret" {
+		t.Errorf("headers = %v", h)
+	}
+	rs := col.requests[0].ResourceSpans[0]
+	if a := rs.Resource.Attributes; len(a) == 0 || a[0].Key != "service.name" || *a[0].Value.StringValue != "shop" {
+		t.Errorf("resource = %+v, want service.name shop", a)
+	}
+	got := rs.ScopeSpans[0].Spans[0]
+	if got.TraceID != span.SpanContext.TraceID.String() || got.SpanID != span.SpanContext.SpanID.String() {
+		t.Errorf("ids = %s/%s", got.TraceID, got.SpanID)
+	}
+	if got.StartTimeUnixNano != "1700000000000000000" || got.EndTimeUnixNano != "1700000000000001500" {
+		t.Errorf("times = %s..%s", got.StartTimeUnixNano, got.EndTimeUnixNano)
+	}
+	if got.Name != span.Name || got.Kind != SpanKindServer || got.Status.Code != SpanStatusError {
+		t.Errorf("span = %+v", got)
+	}
+	if len(got.Attributes) != 2 || got.Attributes[0].Value.IntValue == nil || *got.Attributes[0].Value.IntValue != "500" {
+		t.Errorf("attributes = %+v, want int values as strings", got.Attributes)
+	}
+}
+
+func TestOTLPExporterShutdown(t *testing.T) {
+	col, srv := newCollector(t)
+	e := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL, Interval: time.Hour})
+	for i := 0; i < 3; i++ {
+		e.ExportSpan(testSpan("span"))
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sizes := col.batchSizes(); len(sizes) != 1 || sizes[0] != 3 {
+		t.Fatalf("batch sizes = %v, want the queued spans sent on Shutdown", sizes)
+	}
+
+	e.ExportSpan(testSpan("late"))
+	if e.Dropped() != 1 {
+		t.Errorf("Dropped = %d, want the span after Shutdown counted", e.Dropped())
+	}
+	if err := e.Flush(context.Background()); err != nil {
+		t.Errorf("Flush after Shutdown = %v", err)
+	}
+}
+
+// TestOTLPExporterShutdownRace exports concurrently with Shutdown; every span
+// must be either delivered or counted as dropped.
+func TestOTLPExporterShutdownRace(t *testing.T) {
+	col, srv := newCollector(t)
+	e := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL, Interval: time.Hour})
+
+	const producers, spans = 4, 200
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < spans; i++ {
+				e.ExportSpan(testSpan("span"))
+			}
+		}()
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	delivered := 0
+	for _, n := range col.batchSizes() {
+		delivered += n
+	}
+	if total := delivered + int(e.Dropped()); total != producers*spans {
+		t.Fatalf("delivered %d + dropped %d = %d, want %d", delivered, e.Dropped(), total, producers*spans)
+	}
+}
+
+func TestOTLPExporterLogsErrors(t *testing.T) {
+	col, srv := newCollector(t)
+	col.status = http.StatusServiceUnavailable
+	var buf bytes.Buffer
+	e := NewOTLPExporter(OTLPConfig{
+		Endpoint: srv.URL,
+		Interval: time.Hour,
+		Logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	e.ExportSpan(testSpan("span"))
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "otlp export failed") || !strings.Contains(buf.String(), "503") {
+		t.Fatalf("log = %q, want the failure on the configured logger", buf.String())
+	}
+}
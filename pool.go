@@ -3,6 +3,7 @@ package bolt
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -21,31 +22,68 @@ type ContextPools struct {
 	queryPool    sync.Pool // For routes that use query params
 }
 
+// PoolStats counts the objects taken from a pool and the misses that had
+// to be allocated
+type PoolStats struct {
+	Gets   uint64
+	Misses uint64
+}
+
+// poolCounter tracks PoolStats for one pool. Gets are only counted once
+// enabled, so pools shared by every request do not pay for a contended
+// atomic add unless metrics are served.
+type poolCounter struct {
+	enabled atomic.Bool
+	gets    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+// get counts a Get when counting is enabled.
+func (pc *poolCounter) get() {
+	if pc.enabled.Load() {
+		pc.gets.Add(1)
+	}
+}
+
+func (pc *poolCounter) stats() PoolStats {
+	return PoolStats{Gets: pc.gets.Load(), Misses: pc.misses.Load()}
+}
+
 // ContextPool manages Context object reuse (backwards compatibility)
 type ContextPool struct {
-	pool sync.Pool
+	pool    sync.Pool
+	counter poolCounter
 }
 
 // NewContextPool creates a new context pool with optimized initial sizes.
 func NewContextPool() *ContextPool {
-	return &ContextPool{
-		pool: sync.Pool{
-			New: func() interface{} {
-				return &Context{
-					// Most routes have fewer than 4 params or query values.
-					params: make(ParamMap, DefaultParamsSize),
-					query:  make(QueryValues, DefaultParamsSize),
-				}
-			},
+	p := &ContextPool{}
+	p.pool = sync.Pool{
+		New: func() interface{} {
+			p.counter.misses.Add(1)
+			return &Context{
+				// Most routes have fewer than 4 params or query values.
+				params: make(ParamMap, DefaultParamsSize),
+				query:  make(QueryValues, DefaultParamsSize),
+				locals: make([]local, 0, DefaultParamsSize),
+			}
 		},
 	}
+	return p
 }
 
 // Acquire gets a Context from the pool
 func (p *ContextPool) Acquire() *Context {
+	p.counter.get()
 	return p.pool.Get().(*Context)
 }
 
+// Stats returns the pool's hit and miss counts. Gets are counted once an
+// App serving the pool has metrics enabled.
+func (p *ContextPool) Stats() PoolStats {
+	return p.counter.stats()
+}
+
 // Release returns a Context to the pool with a more aggressive clearing strategy.
 func (p *ContextPool) Release(c *Context) {
 	// Reset basic fields
@@ -55,6 +93,14 @@ func (p *ContextPool) Release(c *Context) {
 	c.StatusCode = 0
 	c.params = nil // The router is responsible for pooling params
 	c.headers = nil
+	c.session = nil
+	c.refs = 0
+	c.bodyLimit = 0
+	c.route = ""
+	c.serverReq = nil
+	c.streaming = false
+	c.writer.release()
+	c.resetLocals()
 
 	// If the query map grew too large, create a new one to prevent memory bloat.
 	// Otherwise, just clear the existing one.
@@ -78,25 +124,34 @@ type SmartBufferPool struct {
 
 // BufferPool manages byte buffer reuse (backwards compatibility)
 type BufferPool struct {
-	pool sync.Pool
+	pool    sync.Pool
+	counter poolCounter
 }
 
 // NewBufferPool creates a new buffer pool that pools *bytes.Buffer objects
 func NewBufferPool() *BufferPool {
-	return &BufferPool{
-		pool: sync.Pool{
-			New: func() interface{} {
-				return new(bytes.Buffer)
-			},
+	p := &BufferPool{}
+	p.pool = sync.Pool{
+		New: func() interface{} {
+			p.counter.misses.Add(1)
+			return new(bytes.Buffer)
 		},
 	}
+	return p
 }
 
 // Acquire gets a buffer from the pool
 func (p *BufferPool) Acquire() *bytes.Buffer {
+	p.counter.get()
 	return p.pool.Get().(*bytes.Buffer)
 }
 
+// Stats returns the pool's hit and miss counts. Gets are counted once an
+// App serving the pool has metrics enabled.
+func (p *BufferPool) Stats() PoolStats {
+	return p.counter.stats()
+}
+
 // Release returns a buffer to the pool after resetting it
 func (p *BufferPool) Release(buf *bytes.Buffer) {
 	buf.Reset()
@@ -205,6 +260,11 @@ func (p *ContextPools) Release(c *Context, poolType string) {
 	c.app = nil
 	c.StatusCode = 0
 	c.params = nil
+	c.session = nil
+	c.refs = 0
+	c.bodyLimit = 0
+	c.writer.release()
+	c.resetLocals()
 
 	switch poolType {
 	case "static":
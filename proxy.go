@@ -0,0 +1,276 @@
+package bolt
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ProxyHeader names the forwarding header that trusted proxies set. Only
+// that header is read; the others are ignored, because a proxy passes
+// through whatever the client sent in headers it does not manage itself.
+type ProxyHeader uint8
+
+const (
+	ProxyXForwarded ProxyHeader = iota // X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host
+	ProxyForwarded                     // RFC 7239 Forwarded
+	ProxyXRealIP                       // X-Real-Ip, client address only
+)
+
+// forwardedHop is one proxy hop as recorded by Forwarded or X-Forwarded-*
+type forwardedHop struct {
+	node  string // for= value or X-Forwarded-For entry
+	proto string
+	host  string
+}
+
+// clientInfo is the client as seen by the first trusted proxy
+type clientInfo struct {
+	ip     string
+	scheme string
+	host   string
+}
+
+// RealIP returns the client IP address. The header chosen by
+// Config.ProxyHeader is only honored when the direct peer is in
+// Config.TrustedProxies; the hops are walked right to left and the first
+// untrusted one is the client. An obfuscated or "unknown" node stops the
+// walk and is returned as is.
+func (c *Context) RealIP() string {
+	return c.client().ip
+}
+
+// Scheme returns "https" or "http" for the client's request, taking
+// X-Forwarded-Proto or Forwarded proto= from trusted proxies into account,
+// whichever Config.ProxyHeader selects.
+func (c *Context) Scheme() string {
+	return c.client().scheme
+}
+
+// Host returns the host the client requested, taking X-Forwarded-Host or
+// Forwarded host= from trusted proxies into account, whichever
+// Config.ProxyHeader selects.
+func (c *Context) Host() string {
+	return c.client().host
+}
+
+// client resolves the client information of the request.
+func (c *Context) client() clientInfo {
+	r := c.Request
+	info := clientInfo{ip: remoteIP(r.RemoteAddr), scheme: "http", host: r.Host}
+	if r.TLS != nil {
+		info.scheme = "https"
+	}
+
+	var proxies []netip.Prefix
+	header := ProxyXForwarded
+	if c.app != nil {
+		proxies = c.app.proxies
+		header = c.app.config.ProxyHeader
+	}
+	if !addrTrusted(info.ip, proxies) {
+		return info
+	}
+
+	if header == ProxyXRealIP {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-Ip")); ip != "" {
+			info.ip = ip
+		}
+		return info
+	}
+	hops := forwardedHops(r.Header, header)
+	if len(hops) == 0 {
+		return info
+	}
+
+	// hops[i] was added by the proxy that received the request from node i.
+	i := len(hops) - 1
+	for i > 0 && addrTrusted(hops[i].node, proxies) {
+		i--
+	}
+	hop := hops[i]
+	info.ip = hop.node
+	if hop.proto != "" {
+		info.scheme = strings.ToLower(hop.proto)
+	}
+	if hop.host != "" {
+		info.host = hop.host
+	}
+	return info
+}
+
+// forwardedHops returns the hops from Forwarded, or from X-Forwarded-For
+// with X-Forwarded-Proto and X-Forwarded-Host aligned from the right.
+func forwardedHops(h http.Header, header ProxyHeader) []forwardedHop {
+	if header == ProxyForwarded {
+		return parseForwarded(strings.Join(h.Values("Forwarded"), ","))
+	}
+
+	nodes := headerList(h, "X-Forwarded-For")
+	if len(nodes) == 0 {
+		return nil
+	}
+	protos := headerList(h, "X-Forwarded-Proto")
+	hosts := headerList(h, "X-Forwarded-Host")
+	hops := make([]forwardedHop, len(nodes))
+	for i, node := range nodes {
+		hops[i].node = node
+		// Proxies that only set proto or host on the first hop produce
+		// shorter lists; the leftmost value then applies to earlier hops.
+		hops[i].proto = alignedValue(protos, len(nodes)-1-i)
+		hops[i].host = alignedValue(hosts, len(nodes)-1-i)
+	}
+	return hops
+}
+
+// alignedValue returns the value fromRight places from the end of list,
+// clamped to its first element.
+func alignedValue(list []string, fromRight int) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[max(len(list)-1-fromRight, 0)]
+}
+
+// headerList splits all values of a comma-separated header.
+func headerList(h http.Header, name string) []string {
+	var list []string
+	for _, v := range h.Values(name) {
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// parseForwarded parses a Forwarded header (RFC 7239) into hops.
+func parseForwarded(v string) []forwardedHop {
+	var hops []forwardedHop
+	for _, elem := range splitQuoted(v, ',') {
+		var hop forwardedHop
+		for _, pair := range splitQuoted(elem, ';') {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "for":
+				hop.node = forwardedNode(value)
+			case "proto":
+				hop.proto = value
+			case "host":
+				hop.host = value
+			}
+		}
+		if hop.node != "" {
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// forwardedNode strips the port and brackets from a for= node.
+func forwardedNode(node string) string {
+	if ap, err := netip.ParseAddrPort(node); err == nil {
+		return ap.Addr().String()
+	}
+	if strings.HasPrefix(node, "[") {
+		return strings.TrimSuffix(node[1:], "]")
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil && !strings.Contains(host, ":") {
+		return host
+	}
+	return node
+}
+
+// splitQuoted splits s at sep outside double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case sep:
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// remoteIP returns the host part of a RemoteAddr.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// addrTrusted reports whether ip is inside one of the prefixes.
+func addrTrusted(ip string, prefixes []netip.Prefix) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses CIDRs and single addresses, panicking on bad input.
+func parsePrefixes(list []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		prefixes = append(prefixes, mustParsePrefix(s))
+	}
+	return prefixes
+}
+
+// mustParsePrefix parses a CIDR or a single address, panicking on bad input.
+func mustParsePrefix(s string) netip.Prefix {
+	p, err := parsePrefix(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// parsePrefix parses a CIDR or a single address. IPv4-mapped IPv6 input is
+// converted to IPv4.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, errors.New("bolt: invalid CIDR " + s)
+		}
+		if p.Addr().Is4In6() {
+			p = netip.PrefixFrom(p.Addr().Unmap(), max(p.Bits()-96, 0))
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, errors.New("bolt: invalid IP " + s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
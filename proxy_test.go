@@ -0,0 +1,83 @@
+package bolt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyClient(t *testing.T) {
+	const proxy = "10.0.0.1:443"
+	const client = "203.0.113.7:51000"
+
+	tests := []struct {
+		name   string
+		header ProxyHeader
+		remote string
+		set    map[string]string
+		want   string // RealIP Scheme Host
+	}{
+		{"no proxy", ProxyXForwarded, client, nil,
+			"203.0.113.7 http example.com"},
+		{"untrusted peer", ProxyXForwarded, client,
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			"203.0.113.7 http example.com"},
+		{"trusted peer", ProxyXForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "shop.example"},
+			"198.51.100.1 https shop.example"},
+		{"spoofed leftmost entry", ProxyXForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.0.0.2"},
+			"198.51.100.1 http example.com"},
+		{"untrusted proxy hop", ProxyXForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "198.51.100.1, 192.0.2.9", "X-Forwarded-Proto": "https, http"},
+			"192.0.2.9 http example.com"},
+		{"only trusted hops", ProxyXForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			"10.0.0.3 http example.com"},
+		{"proto set on first hop only", ProxyXForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.2", "X-Forwarded-Proto": "https"},
+			"198.51.100.1 https example.com"},
+		{"X-Forwarded ignores Forwarded", ProxyXForwarded, proxy,
+			map[string]string{"Forwarded": "for=198.51.100.1;proto=https"},
+			"10.0.0.1 http example.com"},
+		{"Forwarded", ProxyForwarded, proxy,
+			map[string]string{"Forwarded": `for=198.51.100.1;proto=https;host=shop.example, for="[2001:db8::1]:4711"`},
+			"2001:db8::1 http example.com"},
+		{"Forwarded skips trusted hops", ProxyForwarded, proxy,
+			map[string]string{"Forwarded": `for=198.51.100.1;proto=https;host=shop.example, for=10.0.0.2`},
+			"198.51.100.1 https shop.example"},
+		{"Forwarded obfuscated node", ProxyForwarded, proxy,
+			map[string]string{"Forwarded": "for=_hidden, for=10.0.0.2"},
+			"_hidden http example.com"},
+		{"Forwarded ignores X-Forwarded-For", ProxyForwarded, proxy,
+			map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			"10.0.0.1 http example.com"},
+		{"Forwarded from untrusted peer", ProxyForwarded, client,
+			map[string]string{"Forwarded": "for=198.51.100.1"},
+			"203.0.113.7 http example.com"},
+		{"X-Real-Ip", ProxyXRealIP, proxy,
+			map[string]string{"X-Real-Ip": "198.51.100.1", "X-Forwarded-For": "1.1.1.1"},
+			"198.51.100.1 http example.com"},
+		{"X-Real-Ip from untrusted peer", ProxyXRealIP, client,
+			map[string]string{"X-Real-Ip": "198.51.100.1"},
+			"203.0.113.7 http example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(WithTrustedProxies("10.0.0.0/8"), WithProxyHeader(tt.header))
+			app.Get("/", func(c *Context) error {
+				return c.String(http.StatusOK, c.RealIP()+" "+c.Scheme()+" "+c.Host())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.set {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
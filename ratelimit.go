@@ -0,0 +1,356 @@
+package bolt
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitAlgorithm selects how requests are counted
+type RateLimitAlgorithm uint8
+
+const (
+	TokenBucket   RateLimitAlgorithm = iota // Refills Limit tokens per Window, up to Burst
+	SlidingWindow                           // Weighted count over the current and previous window
+	Concurrency                             // Caps in-flight requests at Limit, ignoring keys
+)
+
+// RateLimitRule is the limit a store enforces for one key
+type RateLimitRule struct {
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	Burst     int // Token bucket capacity
+}
+
+// RateLimitResult is the outcome of counting one request
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the quota is fully restored
+	RetryAfter time.Duration // Until the next request is allowed, if denied
+}
+
+// RateLimitStore keeps rate limit state. Implementations backed by shared
+// storage let several instances enforce one limit.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rule RateLimitRule) (RateLimitResult, error)
+}
+
+// RateLimitConfig configures the RateLimit middleware.
+type RateLimitConfig struct {
+	Algorithm RateLimitAlgorithm
+	Limit     int                   // Requests per Window, or in-flight requests for Concurrency
+	Window    time.Duration         // Defaults to one minute
+	Burst     int                   // Token bucket capacity, defaults to Limit
+	Key       func(*Context) string // Defaults to KeyByIP
+	Store     RateLimitStore        // Defaults to a new MemoryRateLimitStore
+	Skip      func(*Context) bool   // Requests to exempt, e.g. health checks
+}
+
+// KeyByIP keys requests by the client IP address, see Context.RealIP.
+func KeyByIP() func(*Context) string {
+	return func(c *Context) string {
+		return "ip:" + c.RealIP()
+	}
+}
+
+// KeyByHeader keys requests by a request header, falling back to the client IP.
+func KeyByHeader(name string) func(*Context) string {
+	return func(c *Context) string {
+		if v := c.Request.Header.Get(name); v != "" {
+			return "h:" + v
+		}
+		return "ip:" + c.RealIP()
+	}
+}
+
+// KeyByAPIKey keys requests by the X-API-Key header or a bearer token,
+// falling back to the client IP.
+func KeyByAPIKey() func(*Context) string {
+	return func(c *Context) string {
+		if v := c.Request.Header.Get("X-API-Key"); v != "" {
+			return "key:" + v
+		}
+		if v, ok := strings.CutPrefix(c.Request.Header.Get("Authorization"), "Bearer "); ok && v != "" {
+			return "key:" + v
+		}
+		return "ip:" + c.RealIP()
+	}
+}
+
+// RateLimit returns middleware that limits requests per key. Every response
+// carries RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers; rejected requests also get Retry-After and
+// ErrTooManyRequests (429) is returned to the ErrorHandler.
+func RateLimit(config RateLimitConfig) Middleware {
+	return newRateLimiter(config, "").middleware
+}
+
+// RateLimit applies a rate limit to the current route, or to every route in
+// the current group. Each route gets its own quota, and in Concurrency mode
+// its own in-flight cap.
+func (cl *ChainLink) RateLimit(config RateLimitConfig) *ChainLink {
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore(0)
+	}
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, newRateLimiter(config, string(v.Method)+" "+v.Path).middleware)
+	case *staticMount:
+		cl.app.rewrapMount(v, newRateLimiter(config, "GET "+v.pattern()).middleware)
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if r := &cl.app.routes[i]; v.contains(r.Group) {
+				cl.app.rewrapRoute(r, newRateLimiter(config, string(r.Method)+" "+r.Path).middleware)
+			}
+		}
+	}
+	return cl
+}
+
+// rateLimiter is one RateLimit middleware instance
+type rateLimiter struct {
+	config RateLimitConfig
+	rule   RateLimitRule
+	scope  string
+	policy string
+	limit  string
+	slots  chan struct{} // Concurrency mode only
+}
+
+// newRateLimiter applies defaults and prepares the header values.
+func newRateLimiter(config RateLimitConfig, scope string) *rateLimiter {
+	if config.Limit <= 0 {
+		panic("bolt: rate limit must be positive")
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	if config.Key == nil {
+		config.Key = KeyByIP()
+	}
+	if config.Store == nil && config.Algorithm != Concurrency {
+		config.Store = NewMemoryRateLimitStore(0)
+	}
+
+	rl := &rateLimiter{
+		config: config,
+		rule: RateLimitRule{
+			Algorithm: config.Algorithm,
+			Limit:     config.Limit,
+			Window:    config.Window,
+			Burst:     config.Burst,
+		},
+		limit: strconv.Itoa(config.Limit),
+	}
+	if scope != "" {
+		rl.scope = scope + "|"
+	}
+	if config.Algorithm == Concurrency {
+		rl.slots = make(chan struct{}, config.Limit)
+	} else {
+		rl.policy = rl.limit + ";w=" + strconv.Itoa(int(math.Ceil(config.Window.Seconds())))
+		if config.Algorithm == TokenBucket && config.Burst != config.Limit {
+			rl.policy += ";burst=" + strconv.Itoa(config.Burst)
+		}
+	}
+	return rl
+}
+
+// middleware counts the request and rejects it once the key is over its limit.
+func (rl *rateLimiter) middleware(next Handler) Handler {
+	if rl.slots != nil {
+		return rl.concurrency(next)
+	}
+	return func(c *Context) error {
+		if rl.config.Skip != nil && rl.config.Skip(c) {
+			return next(c)
+		}
+		res, err := rl.config.Store.Take(c.Request.Context(), rl.scope+rl.config.Key(c), rl.rule)
+		if err != nil {
+			return err
+		}
+
+		h := c.headers
+		h.Set("RateLimit-Limit", rl.limit)
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		h.Set("RateLimit-Policy", rl.policy)
+		if !res.Allowed {
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			return ErrTooManyRequests
+		}
+		return next(c)
+	}
+}
+
+// concurrency caps in-flight requests instead of counting them over time.
+func (rl *rateLimiter) concurrency(next Handler) Handler {
+	return func(c *Context) error {
+		if rl.config.Skip != nil && rl.config.Skip(c) {
+			return next(c)
+		}
+		select {
+		case rl.slots <- struct{}{}:
+		default:
+			c.headers.Set("RateLimit-Limit", rl.limit)
+			c.headers.Set("RateLimit-Remaining", "0")
+			c.headers.Set("Retry-After", "1")
+			return ErrTooManyRequests
+		}
+		defer func() { <-rl.slots }()
+		c.headers.Set("RateLimit-Limit", rl.limit)
+		c.headers.Set("RateLimit-Remaining", strconv.Itoa(cap(rl.slots)-len(rl.slots)))
+		return next(c)
+	}
+}
+
+// ceilSeconds formats d as whole seconds, rounding up.
+func ceilSeconds(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// --- Memory store ---
+
+const rateLimitShards = 64
+
+// MemoryRateLimitStore is a sharded in-process RateLimitStore. Idle keys
+// expire once their quota is restored; each shard sweeps itself lazily, so
+// the store needs no background goroutine.
+type MemoryRateLimitStore struct {
+	seed   maphash.Seed
+	sweep  time.Duration
+	shards [rateLimitShards]rateLimitShard
+}
+
+// rateLimitShard is one lock-protected slice of the key space
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+// rateLimitEntry is the state of one key
+type rateLimitEntry struct {
+	tokens   float64   // Token bucket
+	last     time.Time // Token bucket refill time
+	start    time.Time // Sliding window start
+	current  int
+	previous int
+	expires  time.Time
+}
+
+// NewMemoryRateLimitStore creates a memory store that sweeps expired keys at
+// most every sweepInterval (one minute if 0).
+func NewMemoryRateLimitStore(sweepInterval time.Duration) *MemoryRateLimitStore {
+	if sweepInterval <= 0 {
+		sweepInterval = time.Minute
+	}
+	s := &MemoryRateLimitStore{seed: maphash.MakeSeed(), sweep: sweepInterval}
+	now := time.Now()
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*rateLimitEntry)
+		s.shards[i].lastSweep = now
+	}
+	return s
+}
+
+// Take counts one request for key.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rule RateLimitRule) (RateLimitResult, error) {
+	now := time.Now()
+	shard := &s.shards[maphash.String(s.seed, key)%rateLimitShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) >= s.sweep {
+		for k, e := range shard.entries {
+			if now.After(e.expires) {
+				delete(shard.entries, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	e := shard.entries[key]
+	if e == nil {
+		e = &rateLimitEntry{tokens: float64(rule.Burst), last: now, start: now}
+		shard.entries[key] = e
+	}
+	if rule.Algorithm == SlidingWindow {
+		return e.slidingWindow(now, rule), nil
+	}
+	return e.tokenBucket(now, rule), nil
+}
+
+// tokenBucket refills the bucket and takes a token if one is available.
+func (e *rateLimitEntry) tokenBucket(now time.Time, rule RateLimitRule) RateLimitResult {
+	burst := float64(max(rule.Burst, 1))
+	rate := float64(rule.Limit) / rule.Window.Seconds() // Tokens per second
+
+	e.tokens = min(burst, e.tokens+now.Sub(e.last).Seconds()*rate)
+	e.last = now
+
+	res := RateLimitResult{Limit: rule.Limit}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsDuration((1 - e.tokens) / rate)
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = secondsDuration((burst - e.tokens) / rate)
+	e.expires = now.Add(res.Reset)
+	return res
+}
+
+// slidingWindow estimates the request rate from the current and previous
+// fixed windows, weighting the previous one by how much of it still overlaps.
+func (e *rateLimitEntry) slidingWindow(now time.Time, rule RateLimitRule) RateLimitResult {
+	w := rule.Window
+	if elapsed := now.Sub(e.start); elapsed >= w {
+		windows := elapsed / w
+		if windows == 1 {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.start = e.start.Add(windows * w)
+	}
+	elapsed := now.Sub(e.start)
+	weight := 1 - float64(elapsed)/float64(w)
+	estimate := float64(e.previous)*weight + float64(e.current)
+
+	res := RateLimitResult{Limit: rule.Limit, Reset: w - elapsed}
+	if estimate+1 <= float64(rule.Limit) {
+		e.current++
+		estimate++
+		res.Allowed = true
+	} else if e.current >= rule.Limit || e.previous == 0 {
+		res.RetryAfter = w - elapsed
+	} else {
+		// Wait until the previous window's weight has decayed enough.
+		need := 1 - float64(rule.Limit-1-e.current)/float64(e.previous)
+		res.RetryAfter = max(time.Duration(need*float64(w))-elapsed, time.Second)
+	}
+	res.Remaining = max(rule.Limit-int(math.Ceil(estimate)), 0)
+	e.expires = e.start.Add(2 * w)
+	return res
+}
+
+// secondsDuration converts fractional seconds to a Duration.
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,235 @@
+package bolt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// RequestIDKey is the locals key of the request ID
+const RequestIDKey = "bolt.request-id"
+
+// RequestIDConfig configures the RequestID middleware.
+type RequestIDConfig struct {
+	Header    string        // Defaults to "X-Request-ID"
+	Generator func() string // Defaults to NewUUIDv7, or NewULID
+	Ignore    bool          // Always generate, even if the client sent an ID
+}
+
+// DefaultRequestIDConfig returns the default request ID configuration.
+func DefaultRequestIDConfig() RequestIDConfig {
+	return RequestIDConfig{
+		Header:    "X-Request-ID",
+		Generator: NewUUIDv7,
+	}
+}
+
+// RequestID returns middleware that accepts the request ID sent by the
+// client or a proxy, or generates one, stores it in locals and echoes it in
+// the response. Incoming IDs longer than 128 bytes or with characters other
+// than letters, digits and "-_.:" are replaced.
+func RequestID(config ...RequestIDConfig) Middleware {
+	cfg := DefaultRequestIDConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		def := DefaultRequestIDConfig()
+		if cfg.Header == "" {
+			cfg.Header = def.Header
+		}
+		if cfg.Generator == nil {
+			cfg.Generator = def.Generator
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			id := c.Request.Header.Get(cfg.Header)
+			if cfg.Ignore || !validRequestID(id) {
+				id = cfg.Generator()
+			}
+			c.Set(RequestIDKey, id)
+			c.requestValues().requestID = id
+			c.headers.Set(cfg.Header, id)
+			return next(c)
+		}
+	}
+}
+
+// RequestID returns the ID set by the RequestID middleware, or "".
+func (c *Context) RequestID() string {
+	id, _ := Local[string](c, RequestIDKey)
+	return id
+}
+
+// validRequestID reports whether an incoming ID is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv7 returns a time-ordered UUID (RFC 9562) in canonical form.
+func NewUUIDv7() string {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		panic(err)
+	}
+	ms := uint64(time.Now().UnixMilli())
+	u[0], u[1], u[2], u[3], u[4], u[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	u[6] = u[6]&0x0f | 0x70 // Version 7
+	u[8] = u[8]&0x3f | 0x80 // Variant 10
+
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], u[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], u[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], u[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], u[10:])
+	return string(s[:])
+}
+
+// crockford is the ULID base32 alphabet
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a lexicographically sortable ULID.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+
+	// 128 bits as 26 five-bit digits, most significant first.
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// --- Log correlation ---
+
+// correlationKey is the context.Context key of the request's correlation IDs
+type correlationKey struct{}
+
+// correlation holds the IDs that tie log records to a request
+type correlation struct {
+	requestID string
+	traceID   string
+	spanID    string
+}
+
+// requestValuesKey is the context.Context key of the requestValues layer
+type requestValuesKey struct{}
+
+// requestValues is the one context.Context layer bolt adds to a request. It
+// answers the correlation, SpanContext and Span keys from its fields, so
+// setting them never replaces c.Request again.
+type requestValues struct {
+	context.Context
+	correlation
+	spanContext *SpanContext
+	span        *Span
+}
+
+func (v *requestValues) Value(key any) any {
+	switch key.(type) {
+	case requestValuesKey:
+		return v
+	case correlationKey:
+		return &v.correlation
+	case spanContextKey:
+		if v.spanContext != nil {
+			return v.spanContext
+		}
+	case spanKey:
+		if v.span != nil {
+			return v.span
+		}
+	}
+	return v.Context.Value(key)
+}
+
+// requestValues returns the request's values layer, attaching it to the
+// request's context.Context on first use.
+func (c *Context) requestValues() *requestValues {
+	if v, ok := c.Request.Context().Value(requestValuesKey{}).(*requestValues); ok {
+		return v
+	}
+	v := &requestValues{Context: c.Request.Context()}
+	c.SetContext(v)
+	return v
+}
+
+// CorrelationHandler wraps a slog.Handler so records logged with a request's
+// context, e.g. slog.InfoContext(c.Context(), ...), carry request_id,
+// trace_id and span_id.
+func CorrelationHandler(h slog.Handler) slog.Handler {
+	return correlationHandler{h}
+}
+
+type correlationHandler struct {
+	slog.Handler
+}
+
+func (h correlationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if cr, ok := ctx.Value(correlationKey{}).(*correlation); ok {
+		if cr.requestID != "" {
+			r.AddAttrs(slog.String("request_id", cr.requestID))
+		}
+		if cr.traceID != "" {
+			r.AddAttrs(slog.String("trace_id", cr.traceID), slog.String("span_id", cr.spanID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return correlationHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{h.Handler.WithGroup(name)}
+}
+
+// errorBody adds the request and trace IDs to a JSON error body, so clients
+// can quote them when reporting a failure.
+func (c *Context) errorBody(body []byte) []byte {
+	id, trace := c.RequestID(), c.TraceID()
+	if id == "" && trace == "" || len(body) < 2 || body[len(body)-1] != '}' {
+		return body
+	}
+	out := make([]byte, 0, len(body)+96)
+	out = append(out, body[:len(body)-1]...)
+	if id != "" {
+		out = append(out, `,"request_id":"`...)
+		out = append(out, id...)
+		out = append(out, '"')
+	}
+	if trace != "" {
+		out = append(out, `,"trace_id":"`...)
+		out = append(out, trace...)
+		out = append(out, '"')
+	}
+	return append(out, '}')
+}
@@ -0,0 +1,261 @@
+package bolt
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Optional interfaces supported by the underlying writer
+const (
+	rwFlusher uint8 = 1 << iota
+	rwHijacker
+	rwPusher
+	rwReaderFrom
+)
+
+// responseWriter wraps http.ResponseWriter with cached headers and tracks the
+// status code and body size. It lives inside the pooled Context, and the
+// optional interfaces of the underlying writer are exposed through the
+// pointer-shaped wrapper types below, so wrapping never allocates.
+type responseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	status      int
+	size        int64
+	wroteHeader bool
+	hijacked    bool
+	session     *Context // Commits its session before the status line
+}
+
+// reset prepares the writer for a new request and returns the wrapper that
+// matches the optional interfaces of w.
+func (rw *responseWriter) reset(w http.ResponseWriter) ResponseWriter {
+	rw.w = w
+	rw.header = w.Header()
+	rw.status = 0
+	rw.size = 0
+	rw.wroteHeader = false
+	rw.hijacked = false
+	rw.session = nil
+
+	var flags uint8
+	if _, ok := w.(http.Flusher); ok {
+		flags |= rwFlusher
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		flags |= rwHijacker
+	}
+	if _, ok := w.(http.Pusher); ok {
+		flags |= rwPusher
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		flags |= rwReaderFrom
+	}
+
+	switch flags {
+	case 0:
+		return rwPlain{rw}
+	case rwFlusher:
+		return rwF{rw}
+	case rwHijacker:
+		return rwH{rw}
+	case rwPusher:
+		return rwP{rw}
+	case rwReaderFrom:
+		return rwR{rw}
+	case rwFlusher | rwHijacker:
+		return rwFH{rw}
+	case rwFlusher | rwPusher:
+		return rwFP{rw}
+	case rwFlusher | rwReaderFrom:
+		return rwFR{rw}
+	case rwHijacker | rwPusher:
+		return rwHP{rw}
+	case rwHijacker | rwReaderFrom:
+		return rwHR{rw}
+	case rwPusher | rwReaderFrom:
+		return rwPR{rw}
+	case rwFlusher | rwHijacker | rwPusher:
+		return rwFHP{rw}
+	case rwFlusher | rwHijacker | rwReaderFrom:
+		return rwFHR{rw}
+	case rwFlusher | rwPusher | rwReaderFrom:
+		return rwFPR{rw}
+	case rwHijacker | rwPusher | rwReaderFrom:
+		return rwHPR{rw}
+	default:
+		return rwFHPR{rw}
+	}
+}
+
+// release drops references to the request's writer.
+func (rw *responseWriter) release() {
+	rw.w = nil
+	rw.header = nil
+	rw.session = nil
+}
+
+// commitSession runs the pending session commit, which may still add a
+// Set-Cookie header.
+func (rw *responseWriter) commitSession() {
+	if c := rw.session; c != nil {
+		rw.session = nil
+		c.commitSession()
+	}
+}
+
+// Header returns the cached headers instead of calling the underlying writer's Header()
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader sends the status code once. Informational 1xx codes other
+// than 101 may be sent before the final status.
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader || rw.hijacked {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rw.w.WriteHeader(code)
+		return
+	}
+	rw.commitSession()
+	rw.wroteHeader = true
+	rw.status = code
+	rw.w.WriteHeader(code)
+}
+
+// Write writes body bytes, sending a 200 status first if needed
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.w.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.w
+}
+
+// Status returns the status code sent, or 0 if nothing has been written
+func (rw *responseWriter) Status() int {
+	return rw.status
+}
+
+// Size returns the number of body bytes written
+func (rw *responseWriter) Size() int64 {
+	return rw.size
+}
+
+// Written reports whether the status line has been sent
+func (rw *responseWriter) Written() bool {
+	return rw.wroteHeader || rw.hijacked
+}
+
+func (rw *responseWriter) flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.w.(http.Flusher).Flush()
+}
+
+func (rw *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.commitSession()
+	conn, brw, err := rw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		rw.hijacked = true
+	}
+	return conn, brw, err
+}
+
+func (rw *responseWriter) push(target string, opts *http.PushOptions) error {
+	return rw.w.(http.Pusher).Push(target, opts)
+}
+
+func (rw *responseWriter) readFrom(r io.Reader) (int64, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.w.(io.ReaderFrom).ReadFrom(r)
+	rw.size += n
+	return n, err
+}
+
+// Wrapper types for every combination of optional interfaces.
+// F = http.Flusher, H = http.Hijacker, P = http.Pusher, R = io.ReaderFrom.
+type (
+	rwPlain struct{ *responseWriter }
+	rwF     struct{ *responseWriter }
+	rwH     struct{ *responseWriter }
+	rwP     struct{ *responseWriter }
+	rwR     struct{ *responseWriter }
+	rwFH    struct{ *responseWriter }
+	rwFP    struct{ *responseWriter }
+	rwFR    struct{ *responseWriter }
+	rwHP    struct{ *responseWriter }
+	rwHR    struct{ *responseWriter }
+	rwPR    struct{ *responseWriter }
+	rwFHP   struct{ *responseWriter }
+	rwFHR   struct{ *responseWriter }
+	rwFPR   struct{ *responseWriter }
+	rwHPR   struct{ *responseWriter }
+	rwFHPR  struct{ *responseWriter }
+)
+
+func (w rwF) Flush()    { w.flush() }
+func (w rwFH) Flush()   { w.flush() }
+func (w rwFP) Flush()   { w.flush() }
+func (w rwFR) Flush()   { w.flush() }
+func (w rwFHP) Flush()  { w.flush() }
+func (w rwFHR) Flush()  { w.flush() }
+func (w rwFPR) Flush()  { w.flush() }
+func (w rwFHPR) Flush() { w.flush() }
+
+func (w rwH) Hijack() (net.Conn, *bufio.ReadWriter, error)    { return w.hijack() }
+func (w rwFH) Hijack() (net.Conn, *bufio.ReadWriter, error)   { return w.hijack() }
+func (w rwHP) Hijack() (net.Conn, *bufio.ReadWriter, error)   { return w.hijack() }
+func (w rwHR) Hijack() (net.Conn, *bufio.ReadWriter, error)   { return w.hijack() }
+func (w rwFHP) Hijack() (net.Conn, *bufio.ReadWriter, error)  { return w.hijack() }
+func (w rwFHR) Hijack() (net.Conn, *bufio.ReadWriter, error)  { return w.hijack() }
+func (w rwHPR) Hijack() (net.Conn, *bufio.ReadWriter, error)  { return w.hijack() }
+func (w rwFHPR) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+func (w rwP) Push(target string, opts *http.PushOptions) error    { return w.push(target, opts) }
+func (w rwFP) Push(target string, opts *http.PushOptions) error   { return w.push(target, opts) }
+func (w rwHP) Push(target string, opts *http.PushOptions) error   { return w.push(target, opts) }
+func (w rwPR) Push(target string, opts *http.PushOptions) error   { return w.push(target, opts) }
+func (w rwFHP) Push(target string, opts *http.PushOptions) error  { return w.push(target, opts) }
+func (w rwFPR) Push(target string, opts *http.PushOptions) error  { return w.push(target, opts) }
+func (w rwHPR) Push(target string, opts *http.PushOptions) error  { return w.push(target, opts) }
+func (w rwFHPR) Push(target string, opts *http.PushOptions) error { return w.push(target, opts) }
+
+func (w rwR) ReadFrom(r io.Reader) (int64, error)    { return w.readFrom(r) }
+func (w rwFR) ReadFrom(r io.Reader) (int64, error)   { return w.readFrom(r) }
+func (w rwHR) ReadFrom(r io.Reader) (int64, error)   { return w.readFrom(r) }
+func (w rwPR) ReadFrom(r io.Reader) (int64, error)   { return w.readFrom(r) }
+func (w rwFHR) ReadFrom(r io.Reader) (int64, error)  { return w.readFrom(r) }
+func (w rwFPR) ReadFrom(r io.Reader) (int64, error)  { return w.readFrom(r) }
+func (w rwHPR) ReadFrom(r io.Reader) (int64, error)  { return w.readFrom(r) }
+func (w rwFHPR) ReadFrom(r io.Reader) (int64, error) { return w.readFrom(r) }
+
+// ResponseStatus returns the status code actually sent to the client, or 0
+// if nothing has been written. Unlike StatusCode it also reflects writes
+// made directly through c.Response.
+func (c *Context) ResponseStatus() int {
+	return c.writer.Status()
+}
+
+// ResponseSize returns the number of body bytes written to the client.
+func (c *Context) ResponseSize() int64 {
+	return c.writer.Size()
+}
+
+// Written reports whether the response status has been sent.
+func (c *Context) Written() bool {
+	return c.writer.Written()
+}
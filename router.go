@@ -1,6 +1,8 @@
 package bolt
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"unsafe"
 )
@@ -30,26 +32,44 @@ type Node struct {
 type Router struct {
 	trees      map[HTTPMethod]*Node
 	staticMap  map[HTTPMethod]map[string]Handler // Fast static route cache
+	mounts     map[HTTPMethod][]mount            // Prefix handlers consulted after the tree
 	paramPool  *sync.Pool // Pool for ParamMap to reduce allocations
+	paramStats poolCounter
 	mutex      sync.RWMutex
 }
 
+// mount is a prefix handler kept outside the radix tree, e.g. for static files.
+type mount struct {
+	prefix  string
+	handler Handler
+}
+
 // NewRouter creates a new router.
 func NewRouter() *Router {
-	return &Router{
+	r := &Router{
 		trees:     make(map[HTTPMethod]*Node),
 		staticMap: make(map[HTTPMethod]map[string]Handler),
-		paramPool: &sync.Pool{
-			New: func() interface{} {
-				// Initialize with a default capacity
-				return make(ParamMap, DefaultParamsSize)
-			},
+		mounts:    make(map[HTTPMethod][]mount),
+	}
+	r.paramPool = &sync.Pool{
+		New: func() interface{} {
+			r.paramStats.misses.Add(1)
+			// Initialize with a default capacity
+			return make(ParamMap, DefaultParamsSize)
 		},
 	}
+	return r
+}
+
+// ParamPoolStats returns the hit and miss counts of the ParamMap pool. Gets
+// are counted once the App has metrics enabled.
+func (r *Router) ParamPoolStats() PoolStats {
+	return r.paramStats.stats()
 }
 
 // acquireParamMap gets a ParamMap from the pool.
 func (r *Router) acquireParamMap() ParamMap {
+	r.paramStats.get()
 	return r.paramPool.Get().(ParamMap)
 }
 
@@ -292,3 +312,34 @@ walk:
 	}
 }
 
+// AddMount registers a handler for every path below prefix. Mounts are only
+// consulted when no route matches, longest prefix first.
+func (r *Router) AddMount(method HTTPMethod, prefix string, handler Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.mounts[method] {
+		if r.mounts[method][i].prefix == prefix {
+			r.mounts[method][i].handler = handler // Re-registered with new middleware
+			return
+		}
+	}
+	mounts := append(r.mounts[method], mount{prefix: prefix, handler: handler})
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].prefix) > len(mounts[j].prefix)
+	})
+	r.mounts[method] = mounts
+}
+
+// GetMount returns the handler of the longest mount that contains path.
+func (r *Router) GetMount(method HTTPMethod, path string) Handler {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, m := range r.mounts[method] {
+		if m.prefix == "" || path == m.prefix ||
+			(len(path) > len(m.prefix) && path[len(m.prefix)] == '/' && path[:len(m.prefix)] == m.prefix) {
+			return m.handler
+		}
+	}
+	return nil
+}
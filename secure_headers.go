@@ -0,0 +1,291 @@
+package bolt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// Common CSP source expressions
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPStrictDynamic = "'strict-dynamic'"
+	CSPNonceSource   = "'nonce'" // Replaced with the request's 'nonce-…' value
+)
+
+// CSP builds a Content-Security-Policy. Use CSPNonceSource in a source list
+// to allow elements carrying the per-request nonce from Context.CSPNonce.
+type CSP struct {
+	directives []string
+}
+
+// NewCSP starts an empty policy.
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Directive adds a directive with its sources, e.g. Directive("img-src", CSPSelf, "data:").
+func (p *CSP) Directive(name string, sources ...string) *CSP {
+	d := name
+	if len(sources) > 0 {
+		d += " " + strings.Join(sources, " ")
+	}
+	p.directives = append(p.directives, d)
+	return p
+}
+
+// DefaultSrc sets default-src.
+func (p *CSP) DefaultSrc(sources ...string) *CSP { return p.Directive("default-src", sources...) }
+
+// ScriptSrc sets script-src.
+func (p *CSP) ScriptSrc(sources ...string) *CSP { return p.Directive("script-src", sources...) }
+
+// StyleSrc sets style-src.
+func (p *CSP) StyleSrc(sources ...string) *CSP { return p.Directive("style-src", sources...) }
+
+// ImgSrc sets img-src.
+func (p *CSP) ImgSrc(sources ...string) *CSP { return p.Directive("img-src", sources...) }
+
+// ConnectSrc sets connect-src.
+func (p *CSP) ConnectSrc(sources ...string) *CSP { return p.Directive("connect-src", sources...) }
+
+// FrameAncestors sets frame-ancestors.
+func (p *CSP) FrameAncestors(sources ...string) *CSP {
+	return p.Directive("frame-ancestors", sources...)
+}
+
+// ReportURI sets report-uri, e.g. the path of CSPReportHandler.
+func (p *CSP) ReportURI(uri string) *CSP { return p.Directive("report-uri", uri) }
+
+// String returns the policy with the nonce placeholder left in place.
+func (p *CSP) String() string {
+	return strings.Join(p.directives, "; ")
+}
+
+// DefaultCSP returns a strict nonce-based policy.
+func DefaultCSP() *CSP {
+	return NewCSP().
+		DefaultSrc(CSPSelf).
+		ScriptSrc(CSPSelf, CSPNonceSource, CSPStrictDynamic).
+		StyleSrc(CSPSelf, CSPNonceSource).
+		ImgSrc(CSPSelf, "data:").
+		Directive("object-src", CSPNone).
+		Directive("base-uri", CSPSelf).
+		FrameAncestors(CSPNone)
+}
+
+// SecureHeadersConfig configures the SecureHeaders middleware. Empty string
+// fields leave the corresponding header out.
+type SecureHeadersConfig struct {
+	HSTSMaxAge            time.Duration // Sent over HTTPS only, 0 to omit
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+
+	ContentTypeOptions        string
+	FrameOptions              string
+	ReferrerPolicy            string
+	PermissionsPolicy         string
+	CrossOriginOpenerPolicy   string
+	CrossOriginEmbedderPolicy string
+	CrossOriginResourcePolicy string
+
+	CSP           *CSP // Content-Security-Policy, nil to omit
+	CSPReportOnly bool // Send Content-Security-Policy-Report-Only instead
+
+	HTTPSRedirect bool // Redirect plain HTTP requests with 308, see Context.Scheme
+}
+
+// DefaultSecureHeadersConfig returns a configuration suitable for HTML apps
+// served over HTTPS.
+func DefaultSecureHeadersConfig() SecureHeadersConfig {
+	return SecureHeadersConfig{
+		HSTSMaxAge:                2 * 365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:     true,
+		ContentTypeOptions:        "nosniff",
+		FrameOptions:              "DENY",
+		ReferrerPolicy:            "strict-origin-when-cross-origin",
+		PermissionsPolicy:         "camera=(), microphone=(), geolocation=()",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginResourcePolicy: "same-origin",
+		CSP:                       DefaultCSP(),
+	}
+}
+
+const cspNonceKey = "bolt.csp-nonce"
+
+// SecureHeaders returns middleware that sets security response headers,
+// optionally redirecting HTTP to HTTPS first. When the CSP uses
+// CSPNonceSource, every request gets a fresh nonce for Context.CSPNonce.
+func SecureHeaders(config ...SecureHeadersConfig) Middleware {
+	cfg := DefaultSecureHeadersConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	static := [][2]string{
+		{"X-Content-Type-Options", cfg.ContentTypeOptions},
+		{"X-Frame-Options", cfg.FrameOptions},
+		{"Referrer-Policy", cfg.ReferrerPolicy},
+		{"Permissions-Policy", cfg.PermissionsPolicy},
+		{"Cross-Origin-Opener-Policy", cfg.CrossOriginOpenerPolicy},
+		{"Cross-Origin-Embedder-Policy", cfg.CrossOriginEmbedderPolicy},
+		{"Cross-Origin-Resource-Policy", cfg.CrossOriginResourcePolicy},
+	}
+
+	// Split the policy around the nonce placeholder once.
+	var cspParts []string
+	cspHeader := "Content-Security-Policy"
+	if cfg.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	if cfg.CSP != nil {
+		cspParts = strings.Split(cfg.CSP.String(), CSPNonceSource)
+	}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			secure := c.Scheme() == "https"
+			if cfg.HTTPSRedirect && !secure {
+				return c.Redirect(http.StatusPermanentRedirect, "https://"+c.Host()+c.Request.URL.RequestURI())
+			}
+
+			h := c.headers
+			if secure && hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			for _, kv := range static {
+				if kv[1] != "" {
+					h.Set(kv[0], kv[1])
+				}
+			}
+			switch len(cspParts) {
+			case 0:
+			case 1:
+				h.Set(cspHeader, cspParts[0])
+			default:
+				nonce := newCSPNonce()
+				c.Set(cspNonceKey, nonce)
+				h.Set(cspHeader, strings.Join(cspParts, "'nonce-"+nonce+"'"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// CSPNonce returns the request's CSP nonce for script and style tags, or ""
+// if the policy has no CSPNonceSource.
+func (c *Context) CSPNonce() string {
+	nonce, _ := Local[string](c, cspNonceKey)
+	return nonce
+}
+
+// newCSPNonce returns 128 random bits in base64.
+func newCSPNonce() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// --- CSP reports ---
+
+// CSPReport is a Content-Security-Policy violation report
+type CSPReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+	ScriptSample       string `json:"script-sample"`
+}
+
+// reportingAPIBody is a csp-violation body of the Reporting API
+type reportingAPIBody struct {
+	DocumentURL        string `json:"documentURL"`
+	Referrer           string `json:"referrer"`
+	BlockedURL         string `json:"blockedURL"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	LineNumber         int    `json:"lineNumber"`
+	ColumnNumber       int    `json:"columnNumber"`
+	StatusCode         int    `json:"statusCode"`
+	Sample             string `json:"sample"`
+}
+
+// CSPReportHandler returns a handler for CSP violation reports in both the
+// legacy report-uri format and the Reporting API format. Register it with
+// Post at the path given to CSP.ReportURI.
+func CSPReportHandler(fn func(*Context, CSPReport)) Handler {
+	return func(c *Context) error {
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+		if err != nil {
+			return ErrBadRequest
+		}
+		if c.requestMediaType() == "application/reports+json" {
+			var reports []struct {
+				Type string           `json:"type"`
+				Body reportingAPIBody `json:"body"`
+			}
+			if err := json.Unmarshal(data, &reports); err != nil {
+				return ErrBadRequest
+			}
+			for _, r := range reports {
+				if r.Type != "csp-violation" {
+					continue
+				}
+				b := r.Body
+				fn(c, CSPReport{
+					DocumentURI:        b.DocumentURL,
+					Referrer:           b.Referrer,
+					BlockedURI:         b.BlockedURL,
+					ViolatedDirective:  b.EffectiveDirective,
+					EffectiveDirective: b.EffectiveDirective,
+					OriginalPolicy:     b.OriginalPolicy,
+					Disposition:        b.Disposition,
+					SourceFile:         b.SourceFile,
+					LineNumber:         b.LineNumber,
+					ColumnNumber:       b.ColumnNumber,
+					StatusCode:         b.StatusCode,
+					ScriptSample:       b.Sample,
+				})
+			}
+			return c.NoContent()
+		}
+
+		var legacy struct {
+			Report CSPReport `json:"csp-report"`
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return ErrBadRequest
+		}
+		fn(c, legacy.Report)
+		return c.NoContent()
+	}
+}
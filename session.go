@@ -0,0 +1,505 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store persists encoded session records. Implementations for Redis, SQL or
+// a test fake only need to satisfy these three methods.
+type Store interface {
+	// Get returns the record for id, or ErrSessionNotFound if it is missing or expired.
+	Get(ctx context.Context, id string) ([]byte, error)
+	// Set stores the record for id, expiring it after ttl.
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes the record for id.
+	Delete(ctx context.Context, id string) error
+}
+
+// SessionConfig configures the Session middleware
+type SessionConfig struct {
+	Store           Store
+	CookieName      string
+	IdleTimeout     time.Duration // Expire after this long without a request
+	AbsoluteTimeout time.Duration // Expire this long after creation regardless of activity
+	CookieOptions   []CookieOption
+}
+
+// DefaultSessionConfig returns the default session configuration
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		CookieName:      "bolt_session",
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 24 * time.Hour,
+	}
+}
+
+// sessionRecord is the persisted form of a session
+type sessionRecord struct {
+	Values   map[string]interface{}
+	Flashes  []string
+	Created  time.Time
+	LastSeen time.Time
+}
+
+// SessionData holds per-client state loaded from a Store.
+// Values are encoded with encoding/gob, so custom types must be registered with gob.Register.
+type SessionData struct {
+	id        string
+	oldID     string
+	record    sessionRecord
+	isNew     bool
+	modified  bool
+	destroyed bool
+}
+
+// ID returns the current session ID.
+func (s *SessionData) ID() string {
+	return s.id
+}
+
+// IsNew reports whether the session was created during this request.
+func (s *SessionData) IsNew() bool {
+	return s.isNew
+}
+
+// Get returns a session value or nil.
+func (s *SessionData) Get(key string) interface{} {
+	return s.record.Values[key]
+}
+
+// Set stores a session value.
+func (s *SessionData) Set(key string, value interface{}) {
+	if s.record.Values == nil {
+		s.record.Values = make(map[string]interface{})
+	}
+	s.record.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes a session value.
+func (s *SessionData) Delete(key string) {
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.modified = true
+	}
+}
+
+// AddFlash queues a message that is returned once by Flashes.
+func (s *SessionData) AddFlash(msg string) {
+	s.record.Flashes = append(s.record.Flashes, msg)
+	s.modified = true
+}
+
+// Flashes returns and clears queued flash messages.
+func (s *SessionData) Flashes() []string {
+	flashes := s.record.Flashes
+	if len(flashes) > 0 {
+		s.record.Flashes = nil
+		s.modified = true
+	}
+	return flashes
+}
+
+// RenewID issues a new session ID while keeping the data.
+// Call it on privilege changes such as login to prevent session fixation.
+func (s *SessionData) RenewID() error {
+	id, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = id
+	s.modified = true
+	return nil
+}
+
+// Destroy deletes the session from the store and clears the cookie.
+func (s *SessionData) Destroy() {
+	s.destroyed = true
+}
+
+// newSessionID returns a random 256-bit hex encoded ID.
+func newSessionID() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// sessionManager loads and commits sessions for one middleware instance
+type sessionManager struct {
+	config SessionConfig
+}
+
+// sessionState is the per-request session bookkeeping. It is shared by
+// pointer with shallow copies of the Context, so a session first touched
+// behind Timeout is still committed by the middleware.
+type sessionState struct {
+	manager   *sessionManager
+	data      *SessionData
+	committed bool
+	err       error
+}
+
+// Session returns middleware that makes c.Session available.
+// Sessions are loaded lazily on the first c.Session call, so routes that never
+// touch the session pay no store or cookie cost. A loaded session is committed
+// right before the status line is sent, or when the handler returns if nothing
+// was written; commit errors are returned like handler errors.
+func Session(config SessionConfig) Middleware {
+	defaults := DefaultSessionConfig()
+	if config.CookieName == "" {
+		config.CookieName = defaults.CookieName
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaults.IdleTimeout
+	}
+	if config.AbsoluteTimeout == 0 {
+		config.AbsoluteTimeout = defaults.AbsoluteTimeout
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore(time.Minute)
+	}
+	m := &sessionManager{config: config}
+
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			st := &sessionState{manager: m}
+			c.session = st
+			err := next(c)
+			c.commitSession()
+			if err == nil {
+				err = st.err
+			}
+			return err
+		}
+	}
+}
+
+// Session returns the request session, loading it on first use.
+// It returns ErrNoSession if the Session middleware is not installed.
+func (c *Context) Session() (*SessionData, error) {
+	st := c.session
+	if st == nil {
+		return nil, ErrNoSession
+	}
+	if st.data != nil {
+		return st.data, nil
+	}
+	s, err := st.manager.load(c)
+	if err != nil {
+		return nil, err
+	}
+	st.data = s
+	// Commit the session before the status line reaches the client, whichever
+	// writers are stacked on top of c.writer.
+	c.writer.session = c
+	return s, nil
+}
+
+// commitSession commits a loaded session once, keeping the first error.
+func (c *Context) commitSession() {
+	st := c.session
+	if st == nil || st.data == nil || st.committed {
+		return
+	}
+	st.committed = true
+	st.err = st.manager.commit(c, st.data)
+}
+
+// load restores the session named by the request cookie or starts a new one.
+func (m *sessionManager) load(c *Context) (*SessionData, error) {
+	now := time.Now()
+	if id, err := c.SignedCookie(m.config.CookieName); err == nil && id != "" {
+		data, err := m.config.Store.Get(c.Request.Context(), id)
+		if err == nil {
+			var rec sessionRecord
+			if derr := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); derr == nil &&
+				now.Sub(rec.LastSeen) < m.config.IdleTimeout &&
+				now.Sub(rec.Created) < m.config.AbsoluteTimeout {
+				return &SessionData{id: id, record: rec}, nil
+			}
+			_ = m.config.Store.Delete(c.Request.Context(), id)
+		} else if !errors.Is(err, ErrSessionNotFound) {
+			return nil, err
+		}
+	} else if errors.Is(err, ErrInvalidKey) {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	return &SessionData{
+		id:     id,
+		isNew:  true,
+		record: sessionRecord{Created: now},
+	}, nil
+}
+
+// commit persists the session and writes the cookie if needed.
+func (m *sessionManager) commit(c *Context, s *SessionData) error {
+	ctx := c.Request.Context()
+
+	if s.destroyed {
+		c.ClearCookie(m.config.CookieName, m.config.CookieOptions...)
+		if s.isNew {
+			return nil
+		}
+		return m.config.Store.Delete(ctx, s.id)
+	}
+
+	// Untouched new sessions are never stored.
+	if s.isNew && !s.modified {
+		return nil
+	}
+
+	if s.oldID != "" {
+		if err := m.config.Store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	s.record.LastSeen = now
+	ttl := m.config.IdleTimeout
+	if remaining := s.record.Created.Add(m.config.AbsoluteTimeout).Sub(now); remaining < ttl {
+		ttl = remaining
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s.record); err != nil {
+		return err
+	}
+	if err := m.config.Store.Set(ctx, s.id, buf.Bytes(), ttl); err != nil {
+		return err
+	}
+
+	if s.isNew || s.oldID != "" {
+		return c.SetSignedCookie(m.config.CookieName, s.id, m.config.CookieOptions...)
+	}
+	return nil
+}
+
+// --- Memory store ---
+
+// memoryEntry is a stored session record with its expiry
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// MemoryStore keeps sessions in process memory and sweeps expired entries.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryStore creates an in-memory store that sweeps expired sessions every interval.
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		stop:    make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go s.sweep(sweepInterval)
+	}
+	return s
+}
+
+// Get returns the record for id
+func (s *MemoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.RLock()
+	e, ok := s.entries[id]
+	s.mu.RUnlock()
+	if !ok || time.Now().After(e.expires) {
+		return nil, ErrSessionNotFound
+	}
+	return e.data, nil
+}
+
+// Set stores the record for id
+func (s *MemoryStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	s.mu.Lock()
+	s.entries[id] = memoryEntry{data: stored, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete removes the record for id
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.entries, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Close stops the background sweeper
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// sweep removes expired entries until Close is called.
+func (s *MemoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for id, e := range s.entries {
+				if now.After(e.expires) {
+					delete(s.entries, id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// --- File store ---
+
+// FileStore keeps one file per session in a directory.
+// Each file holds an 8-byte expiry timestamp followed by the record.
+type FileStore struct {
+	dir  string
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewFileStore creates a file-backed store in dir, sweeping expired files every interval.
+func NewFileStore(dir string, sweepInterval time.Duration) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir, stop: make(chan struct{})}
+	if sweepInterval > 0 {
+		go s.sweep(sweepInterval)
+	}
+	return s, nil
+}
+
+// path returns the file for id, rejecting IDs that are not hex.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" {
+		return "", ErrSessionNotFound
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		if !('0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f') {
+			return "", ErrSessionNotFound
+		}
+	}
+	return filepath.Join(s.dir, "sess_"+id), nil
+}
+
+// Get returns the record for id
+func (s *FileStore) Get(_ context.Context, id string) ([]byte, error) {
+	p, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 8 || time.Now().UnixNano() > int64(binary.BigEndian.Uint64(raw)) {
+		return nil, ErrSessionNotFound
+	}
+	return raw[8:], nil
+}
+
+// Set stores the record for id
+func (s *FileStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	p, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	raw := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(raw, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(raw[8:], data)
+
+	// Write to a temp file and rename so readers never see partial records.
+	tmp, err := os.CreateTemp(s.dir, ".tmp_")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Delete removes the record for id
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	p, err := s.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Close stops the background sweeper
+func (s *FileStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// sweep removes expired session files until Close is called.
+func (s *FileStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			matches, _ := filepath.Glob(filepath.Join(s.dir, "sess_*"))
+			for _, p := range matches {
+				f, err := os.Open(p)
+				if err != nil {
+					continue
+				}
+				var head [8]byte
+				_, err = f.Read(head[:])
+				f.Close()
+				if err != nil || now.UnixNano() > int64(binary.BigEndian.Uint64(head[:])) {
+					os.Remove(p)
+				}
+			}
+		}
+	}
+}
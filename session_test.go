@@ -0,0 +1,250 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory Store that records calls and can fail on demand.
+type fakeStore struct {
+	mu      sync.Mutex
+	data    map[string][]byte
+	sets    int
+	deletes int
+	setErr  error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{data: make(map[string][]byte)}
+}
+
+func (s *fakeStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.data[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return d, nil
+}
+
+func (s *fakeStore) Set(_ context.Context, id string, data []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.setErr != nil {
+		return s.setErr
+	}
+	s.sets++
+	s.data[id] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *fakeStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletes++
+	delete(s.data, id)
+	return nil
+}
+
+func newSessionApp(t *testing.T, store Store, mw ...Middleware) *App {
+	t.Helper()
+	kr, err := NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New(WithKeyring(kr))
+	app.Use(Session(SessionConfig{Store: store}))
+	app.Use(mw...)
+	return app
+}
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "bolt_session" {
+			return ck
+		}
+	}
+	return nil
+}
+
+func TestSessionUntouchedIsNotStored(t *testing.T) {
+	store := newFakeStore()
+	app := newSessionApp(t, store)
+	app.Get("/", func(c *Context) error { return c.Text(200, []byte("ok")) })
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if store.sets != 0 || sessionCookie(t, w) != nil {
+		t.Fatalf("sets = %d, cookie = %v; want no store write and no cookie", store.sets, sessionCookie(t, w))
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	store := newFakeStore()
+	app := newSessionApp(t, store)
+	app.Get("/set", func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.Set("user", "ada")
+		return c.Text(200, []byte("ok"))
+	})
+	app.Get("/get", func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		name, _ := s.Get("user").(string)
+		return c.Text(200, []byte(name))
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/set", nil))
+	ck := sessionCookie(t, w)
+	if ck == nil || store.sets != 1 {
+		t.Fatalf("cookie = %v, sets = %d; want a cookie and one store write", ck, store.sets)
+	}
+
+	r := httptest.NewRequest("GET", "/get", nil)
+	r.AddCookie(ck)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	if got := w.Body.String(); got != "ada" {
+		t.Fatalf("body = %q, want %q", got, "ada")
+	}
+}
+
+// TestSessionCommitWithoutWrite covers handlers that return an error or write
+// nothing, including behind writers that replace c.Response.
+func TestSessionCommitWithoutWrite(t *testing.T) {
+	cases := []struct {
+		name string
+		mw   []Middleware
+	}{
+		{"plain", nil},
+		{"compress", []Middleware{Compress()}},
+		{"timeout", []Middleware{Timeout(time.Second)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newFakeStore()
+			app := newSessionApp(t, store, tc.mw...)
+			app.Post("/err", func(c *Context) error {
+				s, err := c.Session()
+				if err != nil {
+					return err
+				}
+				s.AddFlash("failed")
+				return ErrBadRequest
+			})
+			app.Post("/empty", func(c *Context) error {
+				s, err := c.Session()
+				if err != nil {
+					return err
+				}
+				s.Set("seen", true)
+				return nil
+			})
+
+			for _, path := range []string{"/err", "/empty"} {
+				r := httptest.NewRequest("POST", path, nil)
+				r.Header.Set("Accept-Encoding", "gzip")
+				w := httptest.NewRecorder()
+				app.ServeHTTP(w, r)
+				if sessionCookie(t, w) == nil {
+					t.Errorf("%s: no session cookie, status %d", path, w.Code)
+				}
+			}
+			if store.sets != 2 {
+				t.Errorf("sets = %d, want 2", store.sets)
+			}
+		})
+	}
+}
+
+func TestSessionCommitError(t *testing.T) {
+	store := newFakeStore()
+	store.setErr = errors.New("store down")
+	app := newSessionApp(t, store)
+	app.Post("/", func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.Set("k", "v")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
+
+func TestSessionKeepsFlusher(t *testing.T) {
+	store := newFakeStore()
+	app := newSessionApp(t, store)
+	app.Get("/stream", func(c *Context) error {
+		s, err := c.Session()
+		if err != nil {
+			return err
+		}
+		s.Set("k", "v")
+		f, ok := c.Response.(http.Flusher)
+		if !ok {
+			return errors.New("response lost http.Flusher")
+		}
+		_, _ = c.Response.Write([]byte("data: x\n\n"))
+		f.Flush()
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	if w.Code != 200 || !strings.HasPrefix(w.Body.String(), "data: x") {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if sessionCookie(t, w) == nil {
+		t.Fatal("cookie not sent with the flushed headers")
+	}
+}
+
+func TestSessionDestroy(t *testing.T) {
+	store := newFakeStore()
+	app := newSessionApp(t, store)
+	app.Get("/login", func(c *Context) error {
+		s, _ := c.Session()
+		s.Set("user", "ada")
+		return c.Text(200, nil)
+	})
+	app.Get("/logout", func(c *Context) error {
+		s, _ := c.Session()
+		s.Destroy()
+		return c.Text(200, nil)
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+	ck := sessionCookie(t, w)
+
+	r := httptest.NewRequest("GET", "/logout", nil)
+	r.AddCookie(ck)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	if len(store.data) != 0 || store.deletes != 1 {
+		t.Fatalf("data = %d entries, deletes = %d; want the session removed", len(store.data), store.deletes)
+	}
+	if cleared := sessionCookie(t, w); cleared == nil || cleared.MaxAge >= 0 {
+		t.Fatalf("cookie = %v, want it cleared", cleared)
+	}
+}
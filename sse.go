@@ -0,0 +1,345 @@
+package bolt
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// SSEEvent is a single Server-Sent Event
+type SSEEvent struct {
+	ID    string
+	Name  string
+	Data  string
+	Retry time.Duration
+}
+
+// SSEWriter writes a text/event-stream response
+type SSEWriter struct {
+	c           *Context
+	rc          *http.ResponseController
+	buf         []byte
+	lastEventID string
+	mu          sync.Mutex
+}
+
+// SSE registers a GET route that starts an event stream and runs handler.
+// Like WebSocket routes, it is exempt from Config.HandlerTimeout; register
+// streams with SSE rather than Get so the timeout does not cut them off.
+func (a *App) SSE(path string, handler func(*SSEWriter) error) *ChainLink {
+	return a.addStreamingRoute(MethodGet, path, func(c *Context) error {
+		sse, err := c.SSE()
+		if err != nil {
+			return err
+		}
+		return handler(sse)
+	})
+}
+
+// SSE registers an event stream route on the chain's app
+func (cl *ChainLink) SSE(path string, handler func(*SSEWriter) error) *ChainLink {
+	return cl.app.SSE(path, handler)
+}
+
+// SSE starts a text/event-stream response and returns a writer for it.
+// Disconnects are reported through Done and through write errors.
+func (c *Context) SSE() (*SSEWriter, error) {
+	c.headers.Set("Content-Type", "text/event-stream")
+	c.headers.Set("Cache-Control", "no-cache")
+	c.headers.Set("Connection", "keep-alive")
+	c.headers.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.headers.Del("Content-Length")
+
+	c.StatusCode = http.StatusOK
+	c.Response.WriteHeader(http.StatusOK)
+
+	s := &SSEWriter{
+		c:           c,
+		rc:          http.NewResponseController(c.Response),
+		buf:         make([]byte, 0, 256),
+		lastEventID: c.Request.Header.Get("Last-Event-ID"),
+	}
+	if err := s.rc.Flush(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client.
+func (s *SSEWriter) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done is closed when the client disconnects or the request is cancelled.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.c.Request.Context().Done()
+}
+
+// Event sends an event. Empty id and name are omitted, and multi-line data
+// is split into several data fields.
+func (s *SSEWriter) Event(id, name, data string) error {
+	return s.Send(SSEEvent{ID: id, Name: name, Data: data})
+}
+
+// EventJSON sends an event whose data is v encoded as JSON.
+func (s *SSEWriter) EventJSON(id, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Event(id, name, unsafeBytesToString(data))
+}
+
+// Retry tells the client how long to wait before reconnecting.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.Send(SSEEvent{Retry: d})
+}
+
+// Comment sends a comment line, which clients ignore.
+func (s *SSEWriter) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = s.buf[:0]
+	for _, line := range splitSSELines(text) {
+		s.buf = append(s.buf, ": "...)
+		s.buf = append(s.buf, line...)
+		s.buf = append(s.buf, '\n')
+	}
+	s.buf = append(s.buf, '\n')
+	return s.flush()
+}
+
+// Heartbeat sends an empty comment to keep intermediaries from closing the connection.
+func (s *SSEWriter) Heartbeat() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf[:0], ":\n\n"...)
+	return s.flush()
+}
+
+// Send writes a complete event and flushes it to the client.
+func (s *SSEWriter) Send(ev SSEEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = s.buf[:0]
+	if ev.ID != "" {
+		s.buf = append(s.buf, "id: "...)
+		s.buf = append(s.buf, sanitizeSSEField(ev.ID)...)
+		s.buf = append(s.buf, '\n')
+	}
+	if ev.Name != "" {
+		s.buf = append(s.buf, "event: "...)
+		s.buf = append(s.buf, sanitizeSSEField(ev.Name)...)
+		s.buf = append(s.buf, '\n')
+	}
+	if ev.Retry > 0 {
+		s.buf = append(s.buf, "retry: "...)
+		s.buf = strconv.AppendInt(s.buf, ev.Retry.Milliseconds(), 10)
+		s.buf = append(s.buf, '\n')
+	}
+	if ev.Data != "" || (ev.ID == "" && ev.Name == "" && ev.Retry == 0) {
+		for _, line := range splitSSELines(ev.Data) {
+			s.buf = append(s.buf, "data: "...)
+			s.buf = append(s.buf, line...)
+			s.buf = append(s.buf, '\n')
+		}
+	}
+	s.buf = append(s.buf, '\n')
+	return s.flush()
+}
+
+// flush writes the pending buffer. Callers hold s.mu.
+func (s *SSEWriter) flush() error {
+	if err := s.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	if _, err := s.c.Response.Write(s.buf); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Stream sends events from ch until it is closed or the client disconnects,
+// writing a heartbeat comment whenever the stream is idle for heartbeat.
+// A zero heartbeat disables heartbeats.
+func (s *SSEWriter) Stream(ch <-chan SSEEvent, heartbeat time.Duration) error {
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(ev); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := s.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// splitSSELines splits data on any SSE line terminator.
+func splitSSELines(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+	return strings.Split(data, "\n")
+}
+
+// sanitizeSSEField strips line breaks that would end a field early.
+func sanitizeSSEField(v string) string {
+	if strings.ContainsAny(v, "\r\n") {
+		return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+	}
+	return v
+}
+
+// --- Broker ---
+
+// sseSubscriber is a single broker client with a bounded buffer
+type sseSubscriber struct {
+	ch chan SSEEvent
+}
+
+// SSEBroker fans events out to many subscribers. Each subscriber has a
+// bounded buffer; subscribers that fall behind are disconnected so they can
+// reconnect and resume from their Last-Event-ID.
+type SSEBroker struct {
+	mu          sync.RWMutex
+	subscribers map[*sseSubscriber]struct{}
+	bufferSize  int
+	history     []SSEEvent // Ring buffer of recent events for resume
+	historyHead int
+	historyLen  int
+	nextID      uint64
+}
+
+// NewSSEBroker creates a broker with a per-subscriber buffer and a replay
+// history of the given sizes.
+func NewSSEBroker(bufferSize, historySize int) *SSEBroker {
+	if bufferSize <= 0 {
+		bufferSize = 16
+	}
+	return &SSEBroker{
+		subscribers: make(map[*sseSubscriber]struct{}),
+		bufferSize:  bufferSize,
+		history:     make([]SSEEvent, historySize),
+	}
+}
+
+// Publish sends ev to every subscriber without blocking. Events without an
+// ID get a sequential one so clients can resume.
+func (b *SSEBroker) Publish(ev SSEEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	if ev.ID == "" {
+		ev.ID = strconv.FormatUint(b.nextID, 10)
+	}
+	if len(b.history) > 0 {
+		b.history[(b.historyHead+b.historyLen)%len(b.history)] = ev
+		if b.historyLen < len(b.history) {
+			b.historyLen++
+		} else {
+			b.historyHead = (b.historyHead + 1) % len(b.history)
+		}
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- ev:
+		default:
+			// Slow subscriber: drop it rather than block everyone else.
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. If lastEventID is found in the history,
+// the events after it are replayed first. The returned function unsubscribes.
+func (b *SSEBroker) Subscribe(lastEventID string) (<-chan SSEEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []SSEEvent
+	if lastEventID != "" {
+		for i := 0; i < b.historyLen; i++ {
+			if b.history[(b.historyHead+i)%len(b.history)].ID == lastEventID {
+				for j := i + 1; j < b.historyLen; j++ {
+					replay = append(replay, b.history[(b.historyHead+j)%len(b.history)])
+				}
+				break
+			}
+		}
+	}
+
+	size := b.bufferSize
+	if len(replay) > size {
+		size = len(replay)
+	}
+	sub := &sseSubscriber{ch: make(chan SSEEvent, size)}
+	for _, ev := range replay {
+		sub.ch <- ev
+	}
+	b.subscribers[sub] = struct{}{}
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub.ch)
+			}
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Subscribers returns the number of connected subscribers.
+func (b *SSEBroker) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Handler returns a handler that streams broker events to each client,
+// resuming from Last-Event-ID and sending heartbeats while idle. Routes
+// registered with Get are subject to Config.HandlerTimeout; use Serve with
+// App.SSE to exempt the stream.
+func (b *SSEBroker) Handler(heartbeat time.Duration) Handler {
+	serve := b.Serve(heartbeat)
+	return func(c *Context) error {
+		sse, err := c.SSE()
+		if err != nil {
+			return err
+		}
+		return serve(sse)
+	}
+}
+
+// Serve returns an App.SSE handler that streams broker events, e.g.
+//
+//	app.SSE("/events", broker.Serve(15*time.Second))
+func (b *SSEBroker) Serve(heartbeat time.Duration) func(*SSEWriter) error {
+	return func(sse *SSEWriter) error {
+		ch, unsubscribe := b.Subscribe(sse.LastEventID())
+		defer unsubscribe()
+		return sse.Stream(ch, heartbeat)
+	}
+}
@@ -0,0 +1,290 @@
+package bolt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig configures a static file mount
+type StaticConfig struct {
+	Index         string        // Index file for directories, defaults to index.html
+	Browse        bool          // Render a listing for directories without an index
+	SPA           bool          // Serve the root index for unknown extensionless paths
+	Precompressed bool          // Serve .br and .gz sidecar files when accepted
+	MaxAge        time.Duration // Cache-Control max-age for regular files
+	// Immutable reports whether a file name carries a content hash and can be
+	// cached forever. Defaults to detecting names like app.3f2a9c1b.js.
+	Immutable func(name string) bool
+}
+
+// staticMount serves files from an fs.FS below a URL prefix
+type staticMount struct {
+	prefix     string
+	fsys       fs.FS
+	config     StaticConfig
+	etags      sync.Map     // name -> cachedETag
+	middleware []Middleware // App middleware active when the mount was added
+	wrapped    []Middleware // Mount middleware added through the ChainLink
+	options    *pathOptions // Auto-OPTIONS for paths below the mount
+}
+
+// cachedETag is a content hash keyed by the file's size and modification time
+type cachedETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// Static serves files from fsys below prefix. It works with os.DirFS and
+// embed.FS. Mounts live outside the radix tree and are only consulted when no
+// route matches, so they never shadow API routes. The returned ChainLink
+// takes mount-level middleware such as CORS, RateLimit, IPFilter, Timeout and
+// the auth methods; group-level calls do not reach mounts.
+func (a *App) Static(prefix string, fsys fs.FS, config ...StaticConfig) *ChainLink {
+	m := &staticMount{
+		prefix:     strings.TrimSuffix(a.pathBuilder.build(a.prefix, prefix), "/"),
+		fsys:       fsys,
+		middleware: a.middleware,
+		options:    newPathOptions(a.middleware),
+	}
+	if len(config) > 0 {
+		m.config = config[0]
+	}
+	if m.config.Index == "" {
+		m.config.Index = "index.html"
+	}
+	if m.config.Immutable == nil {
+		m.config.Immutable = isHashedAsset
+	}
+
+	a.addMount(m)
+	a.router.AddMount(methodAutoOptions, m.prefix, withRoute(m.pattern(), m.options.serve))
+	return &ChainLink{app: a, subject: m}
+}
+
+// pattern is the route pattern reported by c.Route for the mount.
+func (m *staticMount) pattern() string {
+	return m.prefix + "/*"
+}
+
+// addMount registers the mount's GET and HEAD handlers with its middleware.
+func (a *App) addMount(m *staticMount) {
+	handler := Handler(m.serve)
+	for _, mw := range m.wrapped {
+		handler = mw(handler)
+	}
+	handler = withRoute(m.pattern(), compileMiddleware(m.middleware, handler))
+	a.router.AddMount(MethodGet, m.prefix, handler)
+	a.router.AddMount(MethodHead, m.prefix, handler)
+}
+
+// rewrapMount re-registers a mount with extra middleware, like rewrapRoute.
+func (a *App) rewrapMount(m *staticMount, mw Middleware) {
+	m.wrapped = append(m.wrapped, mw)
+	a.addMount(m)
+}
+
+// serve resolves the request path inside the mount and writes the file.
+func (m *staticMount) serve(c *Context) error {
+	rel := strings.TrimPrefix(c.Request.URL.Path, m.prefix)
+	name := strings.TrimPrefix(path.Clean("/"+rel), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) || strings.Contains(name, `\`) {
+		return ErrNotFound
+	}
+
+	info, err := fs.Stat(m.fsys, name)
+	if err != nil {
+		if m.config.SPA && path.Ext(name) == "" {
+			return m.serveFile(c, m.config.Index)
+		}
+		return ErrNotFound
+	}
+
+	if info.IsDir() {
+		// Redirect so relative links inside the index resolve correctly.
+		if !strings.HasSuffix(c.Request.URL.Path, "/") {
+			target := c.Request.URL.Path + "/"
+			if q := c.Request.URL.RawQuery; q != "" {
+				target += "?" + q
+			}
+			return c.Redirect(http.StatusMovedPermanently, target)
+		}
+		index := path.Join(name, m.config.Index)
+		if _, err := fs.Stat(m.fsys, index); err == nil {
+			return m.serveFile(c, index)
+		}
+		if m.config.Browse {
+			return m.serveListing(c, name)
+		}
+		return ErrNotFound
+	}
+	return m.serveFile(c, name)
+}
+
+// serveFile writes a single file, picking a precompressed sidecar if possible.
+func (m *staticMount) serveFile(c *Context, name string) error {
+	served := name
+	if m.config.Precompressed {
+		accept := c.Request.Header.Get("Accept-Encoding")
+		for _, enc := range [...]struct{ token, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if !acceptsEncoding(accept, enc.token) {
+				continue
+			}
+			if info, err := fs.Stat(m.fsys, name+enc.ext); err == nil && !info.IsDir() {
+				served = name + enc.ext
+				c.headers.Set("Content-Encoding", enc.token)
+				break
+			}
+		}
+		addVary(c.headers, "Accept-Encoding")
+	}
+
+	f, err := m.fsys.Open(served)
+	if err != nil {
+		return ErrNotFound
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return ErrNotFound
+	}
+
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		c.headers.Set("Content-Type", ct)
+	}
+
+	switch {
+	case path.Base(name) == m.config.Index:
+		c.headers.Set("Cache-Control", "no-cache")
+	case m.config.Immutable(path.Base(name)):
+		c.headers.Set("Cache-Control", "public, max-age=31536000, immutable")
+	case m.config.MaxAge > 0:
+		c.headers.Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(m.config.MaxAge/time.Second), 10))
+	default:
+		c.headers.Set("Cache-Control", "no-cache")
+	}
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return c.Stream(ContentType(contentTypeByName(name)), f)
+	}
+	etag, err := m.etag(served, info, rs)
+	if err != nil {
+		return err
+	}
+	c.headers.Set("ETag", etag)
+	return c.serveContent(name, info.ModTime(), info.Size(), rs)
+}
+
+// etag returns a strong ETag from size and mtime, or a content hash when the
+// file system reports no modification time, as embed.FS does.
+func (m *staticMount) etag(name string, info fs.FileInfo, rs io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return `"` + strconv.FormatInt(info.Size(), 36) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 36) + `"`, nil
+	}
+	if v, ok := m.etags.Load(name); ok {
+		cached := v.(cachedETag)
+		if cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			return cached.etag, nil
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	m.etags.Store(name, cachedETag{size: info.Size(), modTime: info.ModTime(), etag: etag})
+	return etag, nil
+}
+
+// serveListing renders a minimal HTML directory index.
+func (m *staticMount) serveListing(c *Context, dir string) error {
+	entries, err := fs.ReadDir(m.fsys, dir)
+	if err != nil {
+		return ErrNotFound
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var b strings.Builder
+	title := html.EscapeString(c.Request.URL.Path)
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>")
+	b.WriteString(title)
+	b.WriteString("</title></head><body><h1>")
+	b.WriteString(title)
+	b.WriteString("</h1><ul>\n")
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		b.WriteString(`<li><a href="`)
+		b.WriteString(html.EscapeString((&url.URL{Path: name}).EscapedPath()))
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(name))
+		b.WriteString("</a></li>\n")
+	}
+	b.WriteString("</ul></body></html>\n")
+
+	c.headers.Set("Cache-Control", "no-cache")
+	return c.HTML(http.StatusOK, b.String())
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows token.
+func acceptsEncoding(header, token string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), token) {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		return params != "q=0" && params != "q=0.0" && params != "q=0.00" && params != "q=0.000"
+	}
+	return false
+}
+
+// isHashedAsset reports whether a file name contains a content hash segment
+// of at least 8 hex characters with both digits and letters, e.g.
+// app.3f2a9c1b.js or chunk-9e1f04ab.css. Dates and version numbers such as
+// report-20240101.pdf do not count.
+func isHashedAsset(name string) bool {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	for _, seg := range strings.FieldsFunc(stem, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+		if len(seg) < 8 {
+			continue
+		}
+		hexOnly, digits, letters := true, false, false
+		for i := 0; i < len(seg); i++ {
+			switch ch := seg[i]; {
+			case '0' <= ch && ch <= '9':
+				digits = true
+			case 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F':
+				letters = true
+			default:
+				hexOnly = false
+			}
+		}
+		if hexOnly && digits && letters {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout returns middleware that cancels the request context after d.
+// If the handler has not finished by then, ErrTimeout is returned to the
+// ErrorHandler (503) and anything the handler writes afterwards is discarded.
+// If the client goes away first, ErrClientClosed (499) is returned instead.
+// The handler's response is buffered until it completes, so streaming
+// handlers should not run behind Timeout.
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: c.Response, h: make(http.Header)}
+
+			// The handler runs on a shallow copy so the ErrorHandler can keep
+			// using c while an abandoned handler is still running.
+			hc := c.shallowCopy()
+			hc.Request = c.Request.WithContext(ctx)
+			hc.Response = hc.writer.reset(tw)
+			hc.headers = tw.h
+			hc.locals = slices.Clone(c.locals)
+
+			// Keep c and its params out of the pool until the handler returns.
+			c.retain()
+
+			done := make(chan error, 1)
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer c.app.releaseContext(c)
+				defer hc.removeMultipartForm(c.Request)
+				defer func() {
+					if p := recover(); p != nil {
+						// Decide under tw.mu whether the middleware is still
+						// waiting; a panic after the deadline has nobody to
+						// propagate to, so it is logged instead.
+						tw.mu.Lock()
+						late := tw.timedOut
+						if !late {
+							panicked <- p
+						}
+						tw.mu.Unlock()
+						if late {
+							c.app.logger().Error("bolt: handler panicked after timeout",
+								"route", c.route, "panic", p, "stack", string(debug.Stack()))
+						}
+					}
+				}()
+				done <- next(hc)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case err := <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.timedOut {
+					tw.flush(c.headers)
+				}
+				c.StatusCode = hc.StatusCode
+				c.locals = hc.locals
+				return err
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				select {
+				case p := <-panicked:
+					tw.mu.Unlock()
+					panic(p)
+				default:
+				}
+				tw.mu.Unlock()
+				if ctx.Err() == context.DeadlineExceeded {
+					return ErrTimeout
+				}
+				return ErrClientClosed
+			}
+		}
+	}
+}
+
+// handlerTimeout is the Timeout installed by Config.HandlerTimeout. Routes
+// registered with App.WebSocket or App.SSE are exempt, since they need the
+// connection beyond any handler deadline and cannot be buffered. The route
+// decides, not the request headers, so clients cannot opt out.
+func handlerTimeout(d time.Duration) Middleware {
+	timeout := Timeout(d)
+	return func(next Handler) Handler {
+		limited := timeout(next)
+		return func(c *Context) error {
+			if c.streaming {
+				return next(c)
+			}
+			return limited(c)
+		}
+	}
+}
+
+// Timeout applies a handler timeout to the current route or to every route
+// in the current group.
+func (cl *ChainLink) Timeout(d time.Duration) *ChainLink {
+	switch v := cl.subject.(type) {
+	case *RouteInfo:
+		cl.app.rewrapRoute(v, Timeout(d))
+	case *staticMount:
+		cl.app.rewrapMount(v, Timeout(d))
+	case *RouteGroup:
+		for i := range cl.app.routes {
+			if v.contains(cl.app.routes[i].Group) {
+				cl.app.rewrapRoute(&cl.app.routes[i], Timeout(d))
+			}
+		}
+	}
+	return cl
+}
+
+// rewrapRoute re-registers a route with extra middleware around its handler.
+func (a *App) rewrapRoute(route *RouteInfo, mw Middleware) {
+	route.wrapped = append(route.wrapped, mw)
+	handler := route.Handler
+	for _, m := range route.wrapped {
+		handler = m(handler)
+	}
+	a.router.AddRoute(route.Method, route.Path, route.withRoute(compileMiddleware(route.middleware, handler)))
+
+	// Keep the stored copy in sync so later rewraps see the same state.
+	for i := range a.routes {
+		if a.routes[i].Method == route.Method && a.routes[i].Path == route.Path {
+			a.routes[i].wrapped = route.wrapped
+		}
+	}
+}
+
+// shallowCopy copies the Context fields without its reference count.
+func (c *Context) shallowCopy() *Context {
+	return &Context{
+		Request:    c.Request,
+		Response:   c.Response,
+		app:        c.app,
+		params:     c.params,
+		query:      c.query,
+		StatusCode: c.StatusCode,
+		headers:    c.headers,
+		fields:     c.fields,
+		session:    c.session,
+		locals:     c.locals,
+		bodyLimit:  c.bodyLimit,
+		route:      c.route,
+	}
+}
+
+// retain keeps c from being released until a matching releaseContext call.
+func (c *Context) retain() {
+	atomic.AddInt32(&c.refs, 1)
+}
+
+// timeoutWriter buffers a handler response until it completes in time
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	h           http.Header
+	buf         bytes.Buffer
+	code        int
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+// Header returns the buffered header map
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// WriteHeader records the status code
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// Write buffers body bytes, failing once the deadline has passed
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// flush copies the buffered response to the real writer. Callers hold tw.mu.
+func (tw *timeoutWriter) flush(dst http.Header) {
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	if !tw.wroteHeader {
+		return
+	}
+	tw.w.WriteHeader(tw.code)
+	_, _ = tw.w.Write(tw.buf.Bytes())
+}
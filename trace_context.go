@@ -0,0 +1,227 @@
+package bolt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// TraceID identifies a trace (W3C Trace Context)
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// IsValid reports whether the ID is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// String returns the lowercase hex form.
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid reports whether the ID is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// String returns the lowercase hex form.
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// FlagSampled is the sampled bit of the trace flags
+const FlagSampled byte = 0x01
+
+// SpanContext is the trace position of a request. SpanID is the span of the
+// request itself; ParentSpanID is the caller's span, if any.
+type SpanContext struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Flags        byte
+	TraceState   string
+}
+
+// Sampled reports whether the trace is being recorded.
+func (sc SpanContext) Sampled() bool { return sc.Flags&FlagSampled != 0 }
+
+// Traceparent returns the traceparent header value for calls made within the
+// span.
+func (sc SpanContext) Traceparent() string {
+	const hexDigits = "0123456789abcdef"
+	b := make([]byte, 0, 55)
+	b = append(b, "00-"...)
+	b = hex.AppendEncode(b, sc.TraceID[:])
+	b = append(b, '-')
+	b = hex.AppendEncode(b, sc.SpanID[:])
+	b = append(b, '-', hexDigits[sc.Flags>>4], hexDigits[sc.Flags&0x0f])
+	return string(b)
+}
+
+// ParseTraceparent parses a traceparent header. Versions other than 00 are
+// accepted as long as they start with the version 00 fields.
+func ParseTraceparent(v string) (sc SpanContext, ok bool) {
+	if len(v) < 55 || v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return sc, false
+	}
+	var version [1]byte
+	if !decodeLowerHex(version[:], v[0:2]) || version[0] == 0xff {
+		return sc, false
+	}
+	if version[0] == 0 && len(v) != 55 || len(v) > 55 && v[55] != '-' {
+		return sc, false
+	}
+	var flags [1]byte
+	if !decodeLowerHex(sc.TraceID[:], v[3:35]) || !decodeLowerHex(sc.ParentSpanID[:], v[36:52]) ||
+		!decodeLowerHex(flags[:], v[53:55]) {
+		return sc, false
+	}
+	if !sc.TraceID.IsValid() || !sc.ParentSpanID.IsValid() {
+		return sc, false
+	}
+	sc.Flags = flags[0]
+	return sc, true
+}
+
+// decodeLowerHex decodes s into dst, rejecting uppercase digits as the
+// specification requires.
+func decodeLowerHex(dst []byte, s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 'A' && s[i] <= 'F' {
+			return false
+		}
+	}
+	n, err := hex.Decode(dst, []byte(s))
+	return err == nil && n == len(dst)
+}
+
+// newTraceID returns a random trace ID.
+func newTraceID() (t TraceID) {
+	if _, err := rand.Read(t[:]); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// newSpanID returns a random span ID.
+func newSpanID() (s SpanID) {
+	if _, err := rand.Read(s[:]); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+const (
+	traceLocalKey     = "bolt.trace"
+	maxTraceStateSize = 512
+)
+
+// TraceContext returns middleware that continues the trace from the
+// traceparent and tracestate headers, or starts a new sampled trace, and
+// gives the request its own span ID. Use Context.TraceID and Context.SpanID
+// for logs and Context.InjectTrace for outgoing calls.
+func TraceContext() Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			c.startSpan()
+			return next(c)
+		}
+	}
+}
+
+// startSpan sets up the request's SpanContext unless one already exists.
+func (c *Context) startSpan() *SpanContext {
+	if sc, ok := Local[*SpanContext](c, traceLocalKey); ok {
+		return sc
+	}
+	sc, ok := ParseTraceparent(c.Request.Header.Get("traceparent"))
+	if ok {
+		if state := c.Request.Header.Values("tracestate"); len(state) > 0 {
+			sc.TraceState = joinTraceState(state)
+		}
+	} else {
+		sc = SpanContext{TraceID: newTraceID(), Flags: FlagSampled}
+	}
+	sc.SpanID = newSpanID()
+
+	c.Set(traceLocalKey, &sc)
+	v := c.requestValues()
+	v.traceID, v.spanID = sc.TraceID.String(), sc.SpanID.String()
+	v.spanContext = &sc
+	return &sc
+}
+
+// joinTraceState combines tracestate header lines, dropping the value if it
+// exceeds the size the specification allows.
+func joinTraceState(values []string) string {
+	state := values[0]
+	for _, v := range values[1:] {
+		state += "," + v
+	}
+	if len(state) > maxTraceStateSize {
+		return ""
+	}
+	return state
+}
+
+// SpanContext returns the request's trace position; ok is false without the
+// TraceContext middleware.
+func (c *Context) SpanContext() (sc SpanContext, ok bool) {
+	p, ok := Local[*SpanContext](c, traceLocalKey)
+	if !ok {
+		return sc, false
+	}
+	return *p, true
+}
+
+// TraceID returns the request's trace ID in hex, or "".
+func (c *Context) TraceID() string {
+	if sc, ok := c.SpanContext(); ok {
+		return sc.TraceID.String()
+	}
+	return ""
+}
+
+// SpanID returns the request's span ID in hex, or "".
+func (c *Context) SpanID() string {
+	if sc, ok := c.SpanContext(); ok {
+		return sc.SpanID.String()
+	}
+	return ""
+}
+
+// InjectTrace sets traceparent and tracestate on the headers of an outgoing
+// request so the callee continues the trace.
+func (c *Context) InjectTrace(h http.Header) {
+	if sc, ok := c.SpanContext(); ok {
+		injectSpanContext(sc, h)
+	}
+}
+
+// spanContextKey is the context.Context key of the request's SpanContext
+type spanContextKey struct{}
+
+// SpanContextFromContext returns the SpanContext stored on a request's
+// context.Context by the TraceContext middleware.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if p, ok := ctx.Value(spanContextKey{}).(*SpanContext); ok {
+		return *p, true
+	}
+	return SpanContext{}, false
+}
+
+// InjectTraceContext sets traceparent and tracestate from ctx, for code that
+// only has the context.Context, e.g.
+//
+//	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+//	bolt.InjectTraceContext(ctx, req.Header)
+func InjectTraceContext(ctx context.Context, h http.Header) {
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		injectSpanContext(sc, h)
+	}
+}
+
+func injectSpanContext(sc SpanContext, h http.Header) {
+	h.Set("traceparent", sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	} else {
+		h.Del("tracestate")
+	}
+}
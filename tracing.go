@@ -0,0 +1,320 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship of a span to its caller (OTLP values)
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+	SpanKindProducer SpanKind = 4
+	SpanKindConsumer SpanKind = 5
+)
+
+// SpanStatus is the outcome of a span (OTLP values)
+type SpanStatus int
+
+const (
+	SpanStatusUnset SpanStatus = 0
+	SpanStatusOK    SpanStatus = 1
+	SpanStatusError SpanStatus = 2
+)
+
+// SpanEvent is a timestamped annotation on a span
+type SpanEvent struct {
+	Name  string
+	Time  time.Time
+	Attrs []slog.Attr
+}
+
+// SpanData is a finished span as handed to a SpanExporter
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Start, End    time.Time
+	Attrs         []slog.Attr
+	Events        []SpanEvent
+	Status        SpanStatus
+	StatusMessage string
+}
+
+// SpanExporter receives finished, sampled spans. ExportSpan must not block;
+// exporters batch and send in the background.
+type SpanExporter interface {
+	ExportSpan(span SpanData)
+}
+
+// Span is a unit of work in progress. All methods are safe for concurrent
+// use, and a nil or unsampled Span records nothing.
+type Span struct {
+	mu       sync.Mutex
+	data     SpanData
+	exporter SpanExporter
+	ended    bool
+}
+
+// SpanContext returns the span's trace position.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// recording reports whether the span's data will be exported.
+func (s *Span) recording() bool {
+	return s != nil && s.exporter != nil && s.data.SpanContext.Sampled()
+}
+
+// SetAttrs adds attributes, e.g. span.SetAttrs(slog.String("db.system", "postgresql")).
+func (s *Span) SetAttrs(attrs ...slog.Attr) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// AddEvent records a named event at the current time.
+func (s *Span) AddEvent(name string, attrs ...slog.Attr) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Events = append(s.data.Events, SpanEvent{Name: name, Time: time.Now(), Attrs: attrs})
+	s.mu.Unlock()
+}
+
+// RecordError adds an exception event and marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.recording() {
+		return
+	}
+	s.AddEvent("exception", slog.String("exception.message", err.Error()))
+	s.SetStatus(SpanStatusError, err.Error())
+}
+
+// SetStatus sets the span outcome. The message is kept for errors only.
+func (s *Span) SetStatus(status SpanStatus, message string) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = status
+	if status == SpanStatusError {
+		s.data.StatusMessage = message
+	}
+	s.mu.Unlock()
+}
+
+// End finishes the span and hands it to the exporter. Later calls are no-ops.
+func (s *Span) End() {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.exporter.ExportSpan(data)
+}
+
+// StartChild starts a span below s, e.g. around a database call.
+func (s *Span) StartChild(name string, attrs ...slog.Attr) *Span {
+	if s == nil {
+		return nil
+	}
+	parent := s.data.SpanContext
+	child := &Span{exporter: s.exporter}
+	child.data = SpanData{
+		Name: name,
+		Kind: SpanKindInternal,
+		SpanContext: SpanContext{
+			TraceID:      parent.TraceID,
+			SpanID:       newSpanID(),
+			ParentSpanID: parent.SpanID,
+			Flags:        parent.Flags,
+			TraceState:   parent.TraceState,
+		},
+		Start: time.Now(),
+		Attrs: attrs,
+	}
+	return child
+}
+
+// InjectTrace sets traceparent and tracestate so a callee continues the
+// trace below this span.
+func (s *Span) InjectTrace(h http.Header) {
+	if s != nil {
+		injectSpanContext(s.data.SpanContext, h)
+	}
+}
+
+// ContextWithSpan returns ctx carrying span, so InjectTraceContext and
+// SpanFromContext pick it up.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	sc := span.SpanContext()
+	ctx = context.WithValue(ctx, spanContextKey{}, &sc)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// spanKey is the context.Context key of the current Span
+type spanKey struct{}
+
+// SpanFromContext returns the span stored by Tracing or ContextWithSpan.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+const spanLocalKey = "bolt.span"
+
+// Span returns the request's server span, or nil without the Tracing
+// middleware.
+func (c *Context) Span() *Span {
+	s, _ := Local[*Span](c, spanLocalKey)
+	return s
+}
+
+// StartSpan starts a child of the request span, e.g.
+//
+//	span := c.StartSpan("db.query", slog.String("db.system", "postgresql"))
+//	defer span.End()
+func (c *Context) StartSpan(name string, attrs ...slog.Attr) *Span {
+	return c.Span().StartChild(name, attrs...)
+}
+
+// --- Middleware ---
+
+// TracingConfig configures the Tracing middleware.
+type TracingConfig struct {
+	Exporter SpanExporter // Required, e.g. NewOTLPExporter
+
+	// Sampler decides whether a new trace is recorded. Requests that carry a
+	// traceparent follow the caller's decision. Defaults to recording all.
+	Sampler func(TraceID) bool
+
+	Skip func(*Context) bool // Requests to leave untraced, e.g. health checks
+}
+
+// RatioSampler records the given fraction of traces. The decision is derived
+// from the trace ID, so every service sampling by ratio agrees.
+func RatioSampler(ratio float64) func(TraceID) bool {
+	switch {
+	case ratio >= 1:
+		return func(TraceID) bool { return true }
+	case ratio <= 0:
+		return func(TraceID) bool { return false }
+	}
+	bound := uint64(ratio * (1 << 63))
+	return func(t TraceID) bool {
+		return binary.BigEndian.Uint64(t[8:])>>1 < bound
+	}
+}
+
+// Tracing returns middleware that records a server span per request, named
+// "METHOD /route/:pattern" and carrying HTTP semantic-convention attributes.
+// It continues the caller's trace from traceparent like TraceContext. Errors
+// are passed to the error handler inside the span so the recorded status is
+// final; 5xx responses mark the span as failed.
+func Tracing(config TracingConfig) Middleware {
+	if config.Exporter == nil {
+		panic("bolt: tracing needs an exporter")
+	}
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if config.Skip != nil && config.Skip(c) {
+				return next(c)
+			}
+			sc := c.startSpan()
+			if !sc.ParentSpanID.IsValid() && config.Sampler != nil && !config.Sampler(sc.TraceID) {
+				sc.Flags &^= FlagSampled
+			}
+
+			r := c.Request
+			name := r.Method
+			if c.route != "" {
+				name += " " + c.route
+			}
+			span := &Span{exporter: config.Exporter}
+			span.data = SpanData{
+				Name:        name,
+				Kind:        SpanKindServer,
+				SpanContext: *sc,
+				Start:       time.Now(),
+			}
+			c.Set(spanLocalKey, span)
+			c.requestValues().span = span
+
+			err := next(c)
+			c.handleError(err)
+
+			if span.recording() {
+				status := c.ResponseStatus()
+				if status == 0 {
+					status = http.StatusOK // net/http sends 200 for empty responses
+				}
+				span.SetAttrs(serverSpanAttrs(c, status)...)
+				if status >= 500 {
+					span.SetAttrs(slog.String("error.type", strconv.Itoa(status)))
+					if err != nil {
+						span.RecordError(err)
+					} else {
+						span.SetStatus(SpanStatusError, "")
+					}
+				}
+			}
+			span.End()
+			return nil
+		}
+	}
+}
+
+// serverSpanAttrs returns the HTTP server attributes of the finished request.
+func serverSpanAttrs(c *Context, status int) []slog.Attr {
+	r := c.Request
+	client := c.client()
+	attrs := make([]slog.Attr, 0, 10)
+	attrs = append(attrs,
+		slog.String("http.request.method", r.Method),
+		slog.String("url.path", r.URL.Path),
+		slog.String("url.scheme", client.scheme),
+		slog.String("server.address", client.host),
+		slog.String("client.address", client.ip),
+		slog.String("network.protocol.version", protocolVersion(r)),
+		slog.Int("http.response.status_code", status),
+		slog.Int64("http.response.body.size", c.ResponseSize()),
+	)
+	if c.route != "" {
+		attrs = append(attrs, slog.String("http.route", c.route))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, slog.String("user_agent.original", ua))
+	}
+	return attrs
+}
+
+// protocolVersion returns "1.1", "2" or "3" as semantic conventions expect.
+func protocolVersion(r *http.Request) string {
+	if r.ProtoMajor >= 2 {
+		return strconv.Itoa(r.ProtoMajor)
+	}
+	return strconv.Itoa(r.ProtoMajor) + "." + strconv.Itoa(r.ProtoMinor)
+}
@@ -1,6 +1,7 @@
 package bolt
 
 import (
+	"log/slog"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -43,6 +44,19 @@ const (
 type RouteGroup struct {
 	Prefix string
 	Doc    RouteDoc
+
+	parent *RouteGroup // Enclosing group of a nested group
+}
+
+// contains reports whether g is group or one of its enclosing groups, so
+// group-level middleware also reaches routes in nested subgroups.
+func (g *RouteGroup) contains(group *RouteGroup) bool {
+	for ; group != nil; group = group.parent {
+		if group == g {
+			return true
+		}
+	}
+	return false
 }
 
 // RouteInfo stores metadata about a registered route.
@@ -52,12 +66,21 @@ type RouteInfo struct {
 	Handler Handler
 	Doc     RouteDoc
 	Group   *RouteGroup // Link to the parent group
+
+	middleware []Middleware    // Middleware active when the route was added
+	wrapped    []Middleware    // Per-route middleware added through the ChainLink
+	security   []routeSecurity // Documented auth requirements
+	streaming  bool            // Registered by App.WebSocket or App.SSE
 }
 
 // ChainLink represents the current state of a fluent configuration chain.
+// Middleware added through a ChainLink, such as Timeout, CORS or RateLimit,
+// wraps the route handler in call order, so each later call becomes the
+// outer layer: .Timeout(d).CORS(p) answers preflights before the timeout
+// starts. Group-level calls also apply to the group's nested subgroups.
 type ChainLink struct {
 	app     *App
-	subject interface{} // The subject can be *RouteInfo or *RouteGroup
+	subject interface{} // The subject can be *RouteInfo, *RouteGroup or *staticMount
 }
 
 // RouteDoc stores documentation metadata for a route
@@ -80,6 +103,14 @@ type Config struct {
 	MaxPoolSize       int
 	PreallocateRoutes int
 	DevMode           bool
+	HandlerTimeout    time.Duration // Timeout for every route except App.WebSocket and App.SSE routes
+	MaxBodySize       int64         // Limit for BindJSON and DecodeStream, 0 disables it
+	Multipart         MultipartConfig
+	Keyring           *Keyring
+	WebSocket         WebSocketConfig
+	TrustedProxies    []string     // CIDRs or IPs whose forwarding header is believed
+	ProxyHeader       ProxyHeader  // The forwarding header those proxies set, X-Forwarded-* by default
+	Logger            *slog.Logger // Framework logs, defaults to slog.Default()
 }
 
 // DocsConfig configures automatic documentation
@@ -105,6 +136,10 @@ type QueryValues url.Values
 // StatusCode represents HTTP status codes
 type StatusCode int
 
+// StatusClientClosedRequest is nginx's non-standard status for requests the
+// client abandoned before the handler finished.
+const StatusClientClosedRequest = 499
+
 // ContentType represents content types
 type ContentType string
 
@@ -0,0 +1,804 @@
+package bolt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	json "github.com/goccy/go-json"
+)
+
+// WSMessageType identifies a WebSocket data message type
+type WSMessageType int
+
+const (
+	WSText   WSMessageType = 1
+	WSBinary WSMessageType = 2
+)
+
+// Frame opcodes from RFC 6455 section 5.2
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// Close status codes from RFC 6455 section 7.4.1
+const (
+	CloseNormal             = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatus           = 1005
+	CloseAbnormal           = 1006
+	CloseInvalidPayload     = 1007
+	ClosePolicyViolation    = 1008
+	CloseMessageTooBig      = 1009
+	CloseMandatoryExtension = 1010
+	CloseInternalError      = 1011
+)
+
+// wsGUID is the handshake GUID from RFC 6455 section 1.3
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsDeflateTail is appended to compressed payloads before inflating
+const wsDeflateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+// wsMaxFrameSize caps a single frame when no read limit is set
+const wsMaxFrameSize = 64 << 20
+
+// wsReadChunk is how much of a frame payload is allocated ahead of the data
+const wsReadChunk = 64 << 10
+
+// WebSocketConfig configures WebSocket upgrades
+type WebSocketConfig struct {
+	ReadLimit         int64         // Maximum message size in bytes, 0 for no limit beyond the 64MB frame cap
+	WriteTimeout      time.Duration // Deadline applied to every write, 0 disables it
+	WriteFragmentSize int           // Split outgoing messages into frames of this size, 0 disables it
+	EnableCompression bool          // Negotiate permessage-deflate
+	CompressionLevel  int
+	Subprotocols      []string
+	CheckOrigin       func(r *http.Request) bool // Defaults to a same-origin check
+}
+
+// DefaultWebSocketConfig returns the default WebSocket configuration
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		ReadLimit:         1 << 20, // 1MB
+		WriteTimeout:      10 * time.Second,
+		EnableCompression: true,
+		CompressionLevel:  flate.BestSpeed,
+	}
+}
+
+// CloseError is returned by reads once the peer has closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Reason
+}
+
+var (
+	errWSProtocol   = errors.New("websocket: protocol error")
+	errWSClosed     = errors.New("websocket: connection closed")
+	errWSBadControl = errors.New("websocket: invalid control frame")
+)
+
+// WSConn is a WebSocket connection, either upgraded by the server or
+// dialed with DialWebSocket. Reads must come from a single goroutine;
+// writes are safe for concurrent use.
+type WSConn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	bw          *bufio.Writer
+	isServer    bool
+	compress    bool
+	level       int
+	readLimit   int64
+	fragment    int
+	writeWait   time.Duration
+	subprotocol string
+	ctx         *Context
+
+	writeMu   sync.Mutex
+	closeSent bool
+	pong      func(data []byte)
+	header    [14]byte
+}
+
+// WebSocket registers a GET route that upgrades to a WebSocket connection and
+// runs handler. Returning an error closes the connection with CloseInternalError.
+func (a *App) WebSocket(path string, handler func(*WSConn) error) *ChainLink {
+	return a.addStreamingRoute(MethodGet, path, func(c *Context) error {
+		ws, err := c.Upgrade()
+		if err != nil {
+			return err
+		}
+		defer ws.conn.Close()
+		if err := handler(ws); err != nil {
+			_ = ws.CloseWithCode(CloseInternalError, "")
+			return nil // The connection is hijacked, nothing left to report
+		}
+		_ = ws.Close()
+		return nil
+	})
+}
+
+// WebSocket registers a WebSocket route on the chain's app
+func (cl *ChainLink) WebSocket(path string, handler func(*WSConn) error) *ChainLink {
+	return cl.app.WebSocket(path, handler)
+}
+
+// Upgrade performs the RFC 6455 handshake and hijacks the connection.
+func (c *Context) Upgrade() (*WSConn, error) {
+	cfg := c.app.config.WebSocket
+	r := c.Request
+
+	if r.Method != http.MethodGet ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, ErrBadRequest
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.headers.Set("Sec-WebSocket-Version", "13")
+		return nil, ErrUpgradeRequired
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, ErrBadRequest
+	}
+	checkOrigin := cfg.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, ErrForbidden
+	}
+
+	subprotocol := ""
+	for _, offered := range headerTokens(r.Header, "Sec-WebSocket-Protocol") {
+		for _, supported := range cfg.Subprotocols {
+			if offered == supported {
+				subprotocol = supported
+				break
+			}
+		}
+		if subprotocol != "" {
+			break
+		}
+	}
+
+	compress := false
+	if cfg.EnableCompression {
+		for _, ext := range headerTokens(r.Header, "Sec-WebSocket-Extensions") {
+			if acceptDeflate(ext) {
+				compress = true
+				break
+			}
+		}
+	}
+
+	netConn, brw, err := http.NewResponseController(c.Response).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// Clear any deadline set by the server's ReadTimeout/WriteTimeout.
+	_ = netConn.SetDeadline(time.Time{})
+
+	var buf bytes.Buffer
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	buf.WriteString(wsAcceptKey(key))
+	buf.WriteString("\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		buf.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	// Keep headers set by middleware, e.g. Set-Cookie or X-Request-ID.
+	_ = c.headers.WriteSubset(&buf, wsHandshakeExclude)
+	buf.WriteString("\r\n")
+	if _, err := netConn.Write(buf.Bytes()); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	c.StatusCode = http.StatusSwitchingProtocols
+
+	ws := newWSConn(netConn, brw.Reader, true, cfg)
+	ws.compress = compress
+	ws.subprotocol = subprotocol
+	ws.ctx = c
+	return ws, nil
+}
+
+// wsHandshakeExclude lists response headers the 101 handshake sets itself or
+// that have no meaning on an upgraded connection.
+var wsHandshakeExclude = map[string]bool{
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Accept":     true,
+	"Sec-Websocket-Protocol":   true,
+	"Sec-Websocket-Extensions": true,
+	"Content-Length":           true,
+	"Content-Type":             true,
+	"Transfer-Encoding":        true,
+}
+
+// acceptDeflate reports whether a permessage-deflate offer (RFC 7692) can be
+// accepted. compress/flate always compresses with a 32 KiB window, so offers
+// limiting server_max_window_bits below 15 are declined, as are offers with
+// unknown parameters.
+func acceptDeflate(offer string) bool {
+	params := strings.Split(offer, ";")
+	if strings.TrimSpace(params[0]) != "permessage-deflate" {
+		return false
+	}
+	for _, p := range params[1:] {
+		name, value, _ := strings.Cut(p, "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.TrimSpace(name) {
+		case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+		case "server_max_window_bits":
+			if value != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newWSConn wraps an established connection.
+func newWSConn(conn net.Conn, br *bufio.Reader, isServer bool, cfg WebSocketConfig) *WSConn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &WSConn{
+		conn:      conn,
+		br:        br,
+		bw:        bufio.NewWriter(conn),
+		isServer:  isServer,
+		level:     cfg.CompressionLevel,
+		readLimit: cfg.ReadLimit,
+		fragment:  cfg.WriteFragmentSize,
+		writeWait: cfg.WriteTimeout,
+	}
+}
+
+// wsAcceptKey computes Sec-WebSocket-Accept for a client key.
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// sameOrigin accepts requests without an Origin or whose Origin host matches Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// headerTokens returns the comma separated tokens of a header.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether a header lists token, ignoring case.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ctx returns the bolt Context of the upgrade request, or nil for dialed connections.
+func (ws *WSConn) Ctx() *Context {
+	return ws.ctx
+}
+
+// Subprotocol returns the negotiated subprotocol.
+func (ws *WSConn) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// Compressed reports whether permessage-deflate was negotiated.
+func (ws *WSConn) Compressed() bool {
+	return ws.compress
+}
+
+// RemoteAddr returns the peer address.
+func (ws *WSConn) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the maximum message size. Larger messages close the
+// connection with CloseMessageTooBig. A limit of 0 only caps single frames
+// at 64MB.
+func (ws *WSConn) SetReadLimit(limit int64) {
+	ws.readLimit = limit
+}
+
+// SetReadDeadline sets the deadline for future reads.
+func (ws *WSConn) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+// SetPongHandler sets a callback for pong frames, e.g. to extend a read deadline.
+func (ws *WSConn) SetPongHandler(fn func(data []byte)) {
+	ws.pong = fn
+}
+
+// --- Reading ---
+
+// ReadMessage reads the next complete data message, answering pings and
+// reassembling fragmented messages. After the peer closes, it returns a *CloseError.
+func (ws *WSConn) ReadMessage() (WSMessageType, []byte, error) {
+	var (
+		msgType    WSMessageType
+		compressed bool
+		started    bool
+		payload    []byte
+	)
+
+	for {
+		fin, rsv1, opcode, data, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if opcode >= wsOpClose {
+			if err := ws.handleControl(opcode, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch opcode {
+		case wsOpContinuation:
+			if !started || rsv1 {
+				return 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+			}
+		case wsOpText, wsOpBinary:
+			if started || (rsv1 && !ws.compress) {
+				return 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+			}
+			started = true
+			compressed = rsv1
+			msgType = WSMessageType(opcode)
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+		}
+
+		if ws.readLimit > 0 && int64(len(payload)+len(data)) > ws.readLimit {
+			return 0, nil, ws.fail(CloseMessageTooBig, ErrRequestTooLarge)
+		}
+		payload = append(payload, data...)
+
+		if !fin {
+			continue
+		}
+
+		if compressed {
+			payload, err = ws.inflate(payload)
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+		if msgType == WSText && !utf8.Valid(payload) {
+			return 0, nil, ws.fail(CloseInvalidPayload, errWSProtocol)
+		}
+		return msgType, payload, nil
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (ws *WSConn) readFrame() (fin, rsv1 bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(ws.br, head[:]); err != nil {
+		return false, false, 0, nil, ws.readError(err)
+	}
+	fin = head[0]&0x80 != 0
+	rsv1 = head[0]&0x40 != 0
+	opcode = head[0] & 0x0F
+	if head[0]&0x30 != 0 {
+		return false, false, 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+	}
+	masked := head[1]&0x80 != 0
+	if masked != ws.isServer {
+		// Clients must mask, servers must not.
+		return false, false, 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, false, 0, nil, ws.readError(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, false, 0, nil, ws.readError(err)
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+		if length>>63 != 0 {
+			// RFC 6455 section 5.2: the most significant bit must be 0.
+			return false, false, 0, nil, ws.fail(CloseProtocolError, errWSProtocol)
+		}
+	}
+
+	if opcode >= wsOpClose && (!fin || length > 125 || rsv1) {
+		return false, false, 0, nil, ws.fail(CloseProtocolError, errWSBadControl)
+	}
+	limit := uint64(wsMaxFrameSize)
+	if ws.readLimit > 0 {
+		limit = uint64(ws.readLimit)
+	}
+	if length > limit {
+		return false, false, 0, nil, ws.fail(CloseMessageTooBig, ErrRequestTooLarge)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(ws.br, mask[:]); err != nil {
+			return false, false, 0, nil, ws.readError(err)
+		}
+	}
+
+	// Grow the buffer as data arrives so a declared length alone cannot
+	// reserve memory the peer never sends.
+	payload = make([]byte, 0, min(length, wsReadChunk))
+	for uint64(len(payload)) < length {
+		n := min(length-uint64(len(payload)), wsReadChunk)
+		payload = slices.Grow(payload, int(n))
+		start := len(payload)
+		payload = payload[:start+int(n)]
+		if _, err = io.ReadFull(ws.br, payload[start:]); err != nil {
+			return false, false, 0, nil, ws.readError(err)
+		}
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, rsv1, opcode, payload, nil
+}
+
+// handleControl processes ping, pong and close frames.
+func (ws *WSConn) handleControl(opcode byte, data []byte) error {
+	switch opcode {
+	case wsOpPing:
+		return ws.writeFrame(wsOpPong, data, false)
+	case wsOpPong:
+		if ws.pong != nil {
+			ws.pong(data)
+		}
+		return nil
+	case wsOpClose:
+		ce := &CloseError{Code: CloseNoStatus}
+		if len(data) == 1 {
+			return ws.fail(CloseProtocolError, errWSBadControl)
+		}
+		if len(data) >= 2 {
+			ce.Code = int(binary.BigEndian.Uint16(data))
+			ce.Reason = string(data[2:])
+			if !validCloseCode(ce.Code) || !utf8.ValidString(ce.Reason) {
+				return ws.fail(CloseProtocolError, errWSBadControl)
+			}
+		}
+		// Echo the close frame to complete the closing handshake.
+		echo := CloseNormal
+		if ce.Code != CloseNoStatus {
+			echo = ce.Code
+		}
+		_ = ws.CloseWithCode(echo, "")
+		return ce
+	default:
+		return ws.fail(CloseProtocolError, errWSProtocol)
+	}
+}
+
+// validCloseCode reports whether a code may appear in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1011:
+		return code != 1004 && code != CloseNoStatus && code != CloseAbnormal
+	}
+	return false
+}
+
+// readError maps network errors to an abnormal closure.
+func (ws *WSConn) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &CloseError{Code: CloseAbnormal}
+	}
+	return err
+}
+
+// fail sends a close frame with code and returns err.
+func (ws *WSConn) fail(code int, err error) error {
+	_ = ws.CloseWithCode(code, "")
+	return err
+}
+
+// inflate decompresses a permessage-deflate payload within the read limit.
+func (ws *WSConn) inflate(payload []byte) ([]byte, error) {
+	fr := acquireFlateReader(io.MultiReader(bytes.NewReader(payload), strings.NewReader(wsDeflateTail)))
+	defer releaseFlateReader(fr)
+
+	var r io.Reader = fr
+	if ws.readLimit > 0 {
+		r = io.LimitReader(fr, ws.readLimit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, ws.fail(CloseInvalidPayload, err)
+	}
+	if ws.readLimit > 0 && int64(len(out)) > ws.readLimit {
+		return nil, ws.fail(CloseMessageTooBig, ErrRequestTooLarge)
+	}
+	return out, nil
+}
+
+// maskBytes applies the RFC 6455 masking algorithm in place.
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (ws *WSConn) ReadJSON(v interface{}) error {
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// --- Writing ---
+
+// WriteMessage sends a complete data message.
+func (ws *WSConn) WriteMessage(t WSMessageType, data []byte) error {
+	if t != WSText && t != WSBinary {
+		return errWSProtocol
+	}
+	opcode := byte(t)
+	compressed := false
+	if ws.compress && len(data) > 0 {
+		deflated, err := deflate(data, ws.level)
+		if err != nil {
+			return err
+		}
+		data = deflated
+		compressed = true
+	}
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return errWSClosed
+	}
+
+	if ws.fragment <= 0 || len(data) <= ws.fragment {
+		return ws.writeFrameLocked(opcode, data, compressed, true)
+	}
+	for first := true; len(data) > 0; first = false {
+		n := min(ws.fragment, len(data))
+		op := byte(wsOpContinuation)
+		if first {
+			op = opcode
+		}
+		if err := ws.writeFrameLocked(op, data[:n], compressed && first, n == len(data)); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// WriteText sends a text message.
+func (ws *WSConn) WriteText(s string) error {
+	return ws.WriteMessage(WSText, []byte(s))
+}
+
+// WriteJSON encodes v as JSON and sends it as a text message.
+func (ws *WSConn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(WSText, data)
+}
+
+// Ping sends a ping frame with an optional payload of up to 125 bytes.
+func (ws *WSConn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errWSBadControl
+	}
+	return ws.writeFrame(wsOpPing, data, false)
+}
+
+// Close performs a normal closure.
+func (ws *WSConn) Close() error {
+	return ws.CloseWithCode(CloseNormal, "")
+}
+
+// CloseWithCode sends a close frame with code and reason, then closes the connection.
+func (ws *WSConn) CloseWithCode(code int, reason string) error {
+	ws.writeMu.Lock()
+	if ws.closeSent {
+		ws.writeMu.Unlock()
+		return nil
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	err := ws.writeFrameLocked(wsOpClose, payload, false, true)
+	ws.closeSent = true
+	ws.writeMu.Unlock()
+
+	_ = ws.conn.Close()
+	return err
+}
+
+// writeFrame sends a single unfragmented frame.
+func (ws *WSConn) writeFrame(opcode byte, data []byte, compressed bool) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return errWSClosed
+	}
+	return ws.writeFrameLocked(opcode, data, compressed, true)
+}
+
+// writeFrameLocked encodes and flushes one frame. Callers hold writeMu.
+func (ws *WSConn) writeFrameLocked(opcode byte, data []byte, compressed, fin bool) error {
+	if ws.writeWait > 0 {
+		_ = ws.conn.SetWriteDeadline(time.Now().Add(ws.writeWait))
+	}
+
+	h := ws.header[:2]
+	h[0] = opcode
+	if fin {
+		h[0] |= 0x80
+	}
+	if compressed {
+		h[0] |= 0x40
+	}
+	h[1] = 0
+	if !ws.isServer {
+		h[1] = 0x80
+	}
+
+	switch n := len(data); {
+	case n <= 125:
+		h[1] |= byte(n)
+	case n <= 0xFFFF:
+		h[1] |= 126
+		h = binary.BigEndian.AppendUint16(h, uint16(n))
+	default:
+		h[1] |= 127
+		h = binary.BigEndian.AppendUint64(h, uint64(n))
+	}
+
+	if ws.isServer {
+		if _, err := ws.bw.Write(h); err != nil {
+			return err
+		}
+		if _, err := ws.bw.Write(data); err != nil {
+			return err
+		}
+		return ws.bw.Flush()
+	}
+
+	var key [4]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+	h = append(h, key[:]...)
+	if _, err := ws.bw.Write(h); err != nil {
+		return err
+	}
+	masked := make([]byte, len(data))
+	copy(masked, data)
+	maskBytes(key, masked)
+	if _, err := ws.bw.Write(masked); err != nil {
+		return err
+	}
+	return ws.bw.Flush()
+}
+
+// --- Compression pools ---
+
+var (
+	flateWriterPools sync.Map // level -> *sync.Pool of *flate.Writer
+	flateReaderPool  sync.Pool
+)
+
+// deflate compresses data without context takeover and strips the sync tail.
+func deflate(data []byte, level int) ([]byte, error) {
+	p, _ := flateWriterPools.LoadOrStore(level, &sync.Pool{})
+	pool := p.(*sync.Pool)
+
+	var buf bytes.Buffer
+	fw, _ := pool.Get().(*flate.Writer)
+	if fw == nil {
+		var err error
+		if fw, err = flate.NewWriter(&buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		fw.Reset(&buf)
+	}
+	defer pool.Put(fw)
+
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	return out[:len(out)-4], nil // Drop 0x00 0x00 0xff 0xff
+}
+
+// acquireFlateReader gets a pooled flate reader reset to r.
+func acquireFlateReader(r io.Reader) io.ReadCloser {
+	if fr, ok := flateReaderPool.Get().(io.ReadCloser); ok {
+		_ = fr.(flate.Resetter).Reset(r, nil)
+		return fr
+	}
+	return flate.NewReader(r)
+}
+
+// releaseFlateReader returns a flate reader to the pool.
+func releaseFlateReader(fr io.ReadCloser) {
+	flateReaderPool.Put(fr)
+}
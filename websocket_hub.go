@@ -0,0 +1,185 @@
+package bolt
+
+import (
+	"bytes"
+	"sync"
+
+	json "github.com/goccy/go-json"
+)
+
+// DefaultWSHubQueueSize is the number of broadcast messages queued per
+// connection before it is treated as a slow consumer.
+const DefaultWSHubQueueSize = 64
+
+// WSHub groups connections into named rooms for broadcasting. Every member
+// connection gets its own bounded send queue and writer goroutine, so one
+// slow client never delays delivery to the rest of a room. A connection whose
+// queue overflows is removed from the hub and closed with ClosePolicyViolation.
+type WSHub struct {
+	mu        sync.RWMutex
+	rooms     map[string]map[*WSConn]struct{}
+	peers     map[*WSConn]*wsPeer
+	queueSize int
+}
+
+// wsPeer is the send queue of one hub member
+type wsPeer struct {
+	queue chan wsOutgoing
+	rooms int  // Rooms the connection is in; the queue closes at zero
+	slow  bool // Removed after its queue overflowed
+}
+
+// wsOutgoing is a queued broadcast message
+type wsOutgoing struct {
+	t    WSMessageType
+	data []byte
+}
+
+// NewWSHub creates an empty hub. queueSize optionally overrides
+// DefaultWSHubQueueSize.
+func NewWSHub(queueSize ...int) *WSHub {
+	h := &WSHub{
+		rooms:     make(map[string]map[*WSConn]struct{}),
+		peers:     make(map[*WSConn]*wsPeer),
+		queueSize: DefaultWSHubQueueSize,
+	}
+	if len(queueSize) > 0 && queueSize[0] > 0 {
+		h.queueSize = queueSize[0]
+	}
+	return h
+}
+
+// Join adds a connection to a room
+func (h *WSHub) Join(room string, ws *WSConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	members := h.rooms[room]
+	if members == nil {
+		members = make(map[*WSConn]struct{})
+		h.rooms[room] = members
+	}
+	if _, ok := members[ws]; ok {
+		return
+	}
+	members[ws] = struct{}{}
+
+	p := h.peers[ws]
+	if p == nil {
+		p = &wsPeer{queue: make(chan wsOutgoing, h.queueSize)}
+		h.peers[ws] = p
+		go h.write(ws, p)
+	}
+	p.rooms++
+}
+
+// Leave removes a connection from a room
+func (h *WSHub) Leave(room string, ws *WSConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(room, ws)
+}
+
+// LeaveAll removes a connection from every room, typically when it closes
+func (h *WSHub) LeaveAll(ws *WSConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range h.rooms {
+		h.leaveLocked(room, ws)
+	}
+}
+
+// leaveLocked removes ws from room, drops empty rooms and stops the writer of
+// a connection that left its last room. Callers hold h.mu.
+func (h *WSHub) leaveLocked(room string, ws *WSConn) {
+	members := h.rooms[room]
+	if _, ok := members[ws]; !ok {
+		return
+	}
+	delete(members, ws)
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+	if p := h.peers[ws]; p != nil {
+		if p.rooms--; p.rooms == 0 {
+			delete(h.peers, ws)
+			close(p.queue)
+		}
+	}
+}
+
+// dropLocked removes ws from every room after its queue overflowed. Callers
+// hold h.mu.
+func (h *WSHub) dropLocked(ws *WSConn) {
+	if p := h.peers[ws]; p != nil {
+		p.slow = true
+	}
+	for room := range h.rooms {
+		h.leaveLocked(room, ws)
+	}
+}
+
+// write sends queued messages to one connection until its queue is closed.
+func (h *WSHub) write(ws *WSConn, p *wsPeer) {
+	failed := false
+	for msg := range p.queue {
+		if failed {
+			continue // Drain so Broadcast never sees a full queue of a dead peer
+		}
+		if err := ws.WriteMessage(msg.t, msg.data); err != nil {
+			failed = true
+			go h.LeaveAll(ws)
+		}
+	}
+	h.mu.RLock()
+	slow := p.slow
+	h.mu.RUnlock()
+	if slow {
+		_ = ws.CloseWithCode(ClosePolicyViolation, "slow consumer")
+	}
+}
+
+// Members returns the number of connections in a room
+func (h *WSHub) Members(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast queues a message for every connection in a room and returns how
+// many connections it was queued for. It never blocks on a client: writes
+// happen on per-connection goroutines, and connections whose queue is full
+// are dropped from the hub. data is copied, so the caller may reuse it.
+func (h *WSHub) Broadcast(room string, t WSMessageType, data []byte) int {
+	msg := wsOutgoing{t: t, data: bytes.Clone(data)}
+	var slow []*WSConn
+
+	h.mu.RLock()
+	sent := 0
+	for ws := range h.rooms[room] {
+		select {
+		case h.peers[ws].queue <- msg:
+			sent++
+		default:
+			slow = append(slow, ws)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) > 0 {
+		h.mu.Lock()
+		for _, ws := range slow {
+			h.dropLocked(ws)
+		}
+		h.mu.Unlock()
+	}
+	return sent
+}
+
+// BroadcastJSON encodes v once and broadcasts it as a text message.
+func (h *WSHub) BroadcastJSON(room string, v interface{}) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return h.Broadcast(room, WSText, data), nil
+}
@@ -0,0 +1,215 @@
+package bolt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func wsURL(srv *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + path
+}
+
+func dialTest(t *testing.T, url string, header http.Header, cfg *WebSocketConfig) (*WSConn, *http.Response) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ws, resp, err := DialWebSocket(ctx, url, header, cfg)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws, resp
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	app := New()
+	app.WebSocket("/echo", func(ws *WSConn) error {
+		for {
+			mt, data, err := ws.ReadMessage()
+			if err != nil {
+				return nil
+			}
+			if err := ws.WriteMessage(mt, data); err != nil {
+				return err
+			}
+		}
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	for _, compress := range []bool{false, true} {
+		cfg := DefaultWebSocketConfig()
+		cfg.EnableCompression = compress
+		cfg.WriteFragmentSize = 1000
+		ws, _ := dialTest(t, wsURL(srv, "/echo"), nil, &cfg)
+		if ws.Compressed() != compress {
+			t.Fatalf("Compressed() = %v, want %v", ws.Compressed(), compress)
+		}
+
+		big := strings.Repeat("bolt ", 10000)
+		for _, msg := range []string{"hello", "", big} {
+			if err := ws.WriteText(msg); err != nil {
+				t.Fatal(err)
+			}
+			mt, data, err := ws.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mt != WSText || string(data) != msg {
+				t.Fatalf("echo = (%d, %d bytes), want (%d, %d bytes)", mt, len(data), WSText, len(msg))
+			}
+		}
+	}
+}
+
+func TestWebSocketHandshakeKeepsHeaders(t *testing.T) {
+	app := New()
+	app.Use(RequestID(), func(next Handler) Handler {
+		return func(c *Context) error {
+			c.SetCookie("seen", "1")
+			return next(c)
+		}
+	})
+	app.WebSocket("/ws", func(ws *WSConn) error { return nil })
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	_, resp := dialTest(t, wsURL(srv, "/ws"), nil, nil)
+	if resp.Header.Get("X-Request-Id") == "" {
+		t.Error("handshake lost X-Request-Id")
+	}
+	if !strings.HasPrefix(resp.Header.Get("Set-Cookie"), "seen=1") {
+		t.Errorf("Set-Cookie = %q, want the middleware cookie", resp.Header.Get("Set-Cookie"))
+	}
+}
+
+func TestWebSocketDeflateNegotiation(t *testing.T) {
+	app := New()
+	app.WebSocket("/ws", func(ws *WSConn) error { return nil })
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	cases := []struct {
+		offer string
+		want  bool
+	}{
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_max_window_bits", true},
+		{"permessage-deflate; server_max_window_bits=15", true},
+		{"permessage-deflate; server_max_window_bits=10", false},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", true},
+		{"permessage-deflate; x-unknown", false},
+	}
+	cfg := DefaultWebSocketConfig()
+	cfg.EnableCompression = false // Send the offer from the test header only
+	for _, tc := range cases {
+		h := http.Header{"Sec-Websocket-Extensions": {tc.offer}}
+		_, resp := dialTest(t, wsURL(srv, "/ws"), h, &cfg)
+		got := strings.HasPrefix(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+		if got != tc.want {
+			t.Errorf("offer %q: accepted = %v, want %v", tc.offer, got, tc.want)
+		}
+	}
+}
+
+func TestWSHubSlowClientDoesNotStallRoom(t *testing.T) {
+	hub := NewWSHub(4)
+	joined := make(chan struct{}, 2)
+	app := New(WithWebSocketConfig(WebSocketConfig{ReadLimit: 1 << 20, WriteTimeout: 500 * time.Millisecond}))
+	app.WebSocket("/room", func(ws *WSConn) error {
+		hub.Join("room", ws)
+		defer hub.LeaveAll(ws)
+		joined <- struct{}{}
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return nil
+			}
+		}
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	cfg := DefaultWebSocketConfig()
+	cfg.EnableCompression = false
+	cfg.ReadLimit = 1 << 20
+	fast, _ := dialTest(t, wsURL(srv, "/room"), nil, &cfg)
+	dialTest(t, wsURL(srv, "/room"), nil, &cfg) // Never reads
+	<-joined
+	<-joined
+
+	received := make(chan struct{})
+	go func() {
+		for {
+			if _, _, err := fast.ReadMessage(); err != nil {
+				return
+			}
+			received <- struct{}{}
+		}
+	}()
+
+	msg := make([]byte, 256<<10)
+	_, _ = rand.Read(msg)
+	const messages = 100
+	start := time.Now()
+	for i := 0; i < messages; i++ {
+		began := time.Now()
+		if hub.Broadcast("room", WSBinary, msg) == 0 {
+			t.Fatalf("message %d reached no member", i)
+		}
+		if d := time.Since(began); d > 100*time.Millisecond {
+			t.Fatalf("Broadcast %d blocked for %v", i, d)
+		}
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("fast client stalled at message %d", i)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("broadcast took %v", elapsed)
+	}
+	if n := hub.Members("room"); n != 1 {
+		t.Errorf("Members = %d, want the slow client dropped", n)
+	}
+}
+
+func TestWebSocketFrameLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int64
+		ext   uint64
+		code  int
+	}{
+		{"msb set", 0, 1 << 63, CloseProtocolError},
+		{"over frame cap without limit", 0, 1 << 40, CloseMessageTooBig},
+		{"over read limit", 1024, 2048, CloseMessageTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverEnd, clientEnd := net.Pipe()
+			defer clientEnd.Close()
+			server := newWSConn(serverEnd, nil, true, WebSocketConfig{ReadLimit: tt.limit})
+			client := newWSConn(clientEnd, nil, false, WebSocketConfig{})
+
+			frame := []byte{0x82, 0x80 | 127}
+			frame = binary.BigEndian.AppendUint64(frame, tt.ext)
+			go func() { _, _ = clientEnd.Write(frame) }()
+			go func() { _, _, _ = server.ReadMessage() }()
+
+			_ = clientEnd.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, _, err := client.ReadMessage()
+			var ce *CloseError
+			if !errors.As(err, &ce) || ce.Code != tt.code {
+				t.Fatalf("err = %v, want close code %d", err, tt.code)
+			}
+		})
+	}
+}